@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"monkey/token"
 	"strings"
 )
@@ -21,6 +22,13 @@ type Expression interface {
 	expressionNode()
 }
 
+// TypeExpr is an optional type annotation such as `int`, `[string]` or
+// `fn(int) -> bool`. The evaluator ignores them; see package types.
+type TypeExpr interface {
+	Node
+	typeNode()
+}
+
 type Program struct {
 	Statements []Statement
 }
@@ -43,10 +51,14 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// LetStatement binds Value to Name or, for let [a, b] = v; and
+// let {a, b} = v;, destructures it into the names of Pattern. Exactly one
+// of Name and Pattern is set.
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token
+	Name    *Identifier
+	Pattern Pattern
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
@@ -56,7 +68,14 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString((ls.TokenLiteral() + " "))
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+		if ls.Name.Type != nil {
+			out.WriteString(": " + ls.Name.Type.String())
+		}
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -71,6 +90,16 @@ func (ls *LetStatement) String() string {
 type Identifier struct {
 	Token token.Token
 	Value string
+	Type  TypeExpr
+	Local *Local
+}
+
+// Local locates a variable in the environments of the enclosing function
+// calls: Depth calls up the chain, in slot Slot. The evaluator's resolver
+// fills it in; identifiers without one are looked up by name.
+type Local struct {
+	Depth int
+	Slot  int
 }
 
 func (i *Identifier) expressionNode()      {}
@@ -115,15 +144,27 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+// IntegerLiteral is an integer literal. Big holds literals too large for
+// an int64, in which case Value is zero.
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -187,7 +228,7 @@ func (ie *IfExpression) String() string {
 	out.WriteString("if")
 	out.WriteString(ie.Condition.String())
 	out.WriteString(" ")
-	out.WriteString(ie.Condition.String())
+	out.WriteString(ie.Consequence.String())
 
 	if ie.Alternative != nil {
 		out.WriteString("else ")
@@ -214,10 +255,21 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// FunctionLiteral is fn(Parameters) -> ReturnType { Body }. Defaults is
+// nil or holds the default value of each parameter, nil for required
+// ones. If Variadic is set the last parameter is written ...name and
+// collects the remaining arguments into an array. Generator is set when
+// Body yields: calling the function then returns an iterator over the
+// values it yields.
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
+	Defaults   []Expression
+	Variadic   bool
+	ReturnType TypeExpr
 	Body       *BlockStatement
+	Slots      int
+	Generator  bool
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -226,23 +278,67 @@ func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+	for i, p := range fl.Parameters {
+		param := p.String()
+		if fl.Variadic && i == len(fl.Parameters)-1 {
+			param = "..." + param
+		}
+		if p.Type != nil {
+			param += ": " + p.Type.String()
+		}
+		if fl.Default(i) != nil {
+			param += " = " + fl.Default(i).String()
+		}
+		params = append(params, param)
 	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
 	out.WriteString(fl.Body.String())
 
 	return out.String()
 }
 
+// Default returns the default value of parameter i, or nil if it has none.
+func (fl *FunctionLiteral) Default(i int) Expression {
+	if i < len(fl.Defaults) {
+		return fl.Defaults[i]
+	}
+	return nil
+}
+
+// Arity returns the smallest and largest number of arguments the function
+// accepts. max is -1 for variadic functions.
+func (fl *FunctionLiteral) Arity() (min, max int) {
+	max = len(fl.Parameters)
+	if fl.Variadic {
+		max--
+	}
+	for i := 0; i < max; i++ {
+		if fl.Default(i) == nil {
+			min++
+		}
+	}
+	if fl.Variadic {
+		max = -1
+	}
+	return min, max
+}
+
+// CallExpression is Function(Arguments). Besides ordinary expressions an
+// argument may be a SpreadExpr or, after the positional ones, a
+// NamedArgument. The parser sets Tail on calls whose value is the value
+// of the enclosing function.
 type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	Tail      bool
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -263,6 +359,31 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// SpreadExpr is ...Value among the arguments of a call, which passes the
+// elements of the array Value as separate arguments.
+type SpreadExpr struct {
+	Token token.Token
+	Value Expression
+}
+
+func (se *SpreadExpr) expressionNode()      {}
+func (se *SpreadExpr) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpr) String() string       { return "..." + se.Value.String() }
+
+// NamedArgument is Name: Value among the arguments of a call, which passes
+// Value as the parameter called Name.
+type NamedArgument struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode()      {}
+func (na *NamedArgument) TokenLiteral() string { return na.Token.Literal }
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -311,3 +432,146 @@ func (ie *IndexExpr) String() string {
 	out.WriteString("])")
 	return out.String()
 }
+
+// MemberExpr is object.property, such as math.sqrt.
+type MemberExpr struct {
+	Token    token.Token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpr) expressionNode()      {}
+func (me *MemberExpr) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpr) String() string {
+	return "(" + me.Object.String() + "." + me.Property.String() + ")"
+}
+
+// SliceExpr is left[start:end]; Start and End may each be nil.
+type SliceExpr struct {
+	Token token.Token
+	Left  Expression
+	Start Expression
+	End   Expression
+}
+
+func (se *SliceExpr) expressionNode()      {}
+func (se *SliceExpr) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpr) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
+
+// RangeExpr is Start..End, or Start..=End if Inclusive.
+type RangeExpr struct {
+	Token     token.Token
+	Start     Expression
+	End       Expression
+	Inclusive bool
+}
+
+func (re *RangeExpr) expressionNode()      {}
+func (re *RangeExpr) TokenLiteral() string { return re.Token.Literal }
+func (re *RangeExpr) String() string {
+	return "(" + re.Start.String() + re.Token.Literal + re.End.String() + ")"
+}
+
+type MacroLiteral struct {
+	Token      token.Token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (ml *MacroLiteral) expressionNode()      {}
+func (ml *MacroLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MacroLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ml.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(ml.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(ml.Body.String())
+
+	return out.String()
+}
+
+type NamedType struct {
+	Token token.Token
+	Name  string
+}
+
+func (nt *NamedType) typeNode()            {}
+func (nt *NamedType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NamedType) String() string       { return nt.Name }
+
+type ArrayType struct {
+	Token   token.Token
+	Element TypeExpr
+}
+
+func (at *ArrayType) typeNode()            {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) String() string       { return "[" + at.Element.String() + "]" }
+
+type FunctionType struct {
+	Token      token.Token
+	Parameters []TypeExpr
+	Return     TypeExpr
+}
+
+func (ft *FunctionType) typeNode()            {}
+func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") -> ")
+	out.WriteString(ft.Return.String())
+
+	return out.String()
+}
+
+type HashLiteral struct {
+	Token  token.Token
+	Keys   []Expression
+	Values []Expression
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for i, key := range hl.Keys {
+		pairs = append(pairs, key.String()+": "+hl.Values[i].String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
@@ -0,0 +1,81 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+	"strings"
+)
+
+// EnumStatement is enum Name { Variant(fields), ... }. It binds Name to
+// the enum, whose members are the variants: a constructor for each
+// variant with fields, and the value itself for each without.
+type EnumStatement struct {
+	Token    token.Token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+
+	variants := []string{}
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString("enum ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// EnumVariant is one variant of an enum, Name(Fields). Fields is empty
+// for a variant written as just Name.
+type EnumVariant struct {
+	Token  token.Token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ev *EnumVariant) TokenLiteral() string { return ev.Token.Literal }
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+
+	fields := []string{}
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// VariantPattern is Enum.Variant(Args), which matches values of that
+// variant whose fields match Args in order. A variant without fields is
+// matched by Enum.Variant alone.
+type VariantPattern struct {
+	Token   token.Token
+	Enum    *Identifier
+	Variant *Identifier
+	Args    []Pattern
+}
+
+func (vp *VariantPattern) patternNode()         {}
+func (vp *VariantPattern) TokenLiteral() string { return vp.Token.Literal }
+func (vp *VariantPattern) String() string {
+	name := vp.Enum.String() + "." + vp.Variant.String()
+	if len(vp.Args) == 0 {
+		return name
+	}
+
+	args := []string{}
+	for _, a := range vp.Args {
+		args = append(args, a.String())
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+)
+
+// YieldExpr is yield Value. It hands Value to whoever is iterating over
+// the generator it is evaluated in, and waits until the next value is
+// asked for.
+type YieldExpr struct {
+	Token token.Token
+	Value Expression
+}
+
+func (ye *YieldExpr) expressionNode()      {}
+func (ye *YieldExpr) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpr) String() string       { return "yield " + ye.Value.String() }
+
+// ForStatement is for (Target in Iterable) { Body }. Body runs once for
+// each value of Iterable, with the names in Target bound to its parts.
+type ForStatement struct {
+	Token    token.Token
+	Target   Pattern
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(fs.Target.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
@@ -0,0 +1,159 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+	"strings"
+)
+
+// MatchExpression is match (Subject) { arm, ... }. The value of the first
+// arm whose pattern matches the subject and whose guard, if any, is true
+// is the value of the expression.
+type MatchExpression struct {
+	Token   token.Token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// MatchArm is Pattern if Guard => Body. Guard may be nil. An arm whose
+// body is a single expression gets a Body holding just that expression.
+type MatchArm struct {
+	Token   token.Token
+	Pattern Pattern
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+// Pattern is the shape a value is matched against.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// WildcardPattern is _, which matches anything and binds nothing.
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (wp *WildcardPattern) patternNode()         {}
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string       { return "_" }
+
+// BindingPattern matches anything and binds it to Name. Inside an array
+// or hash pattern it may have a Default, bound instead when the element
+// or key is missing.
+type BindingPattern struct {
+	Token   token.Token
+	Name    *Identifier
+	Default Expression
+}
+
+func (bp *BindingPattern) patternNode()         {}
+func (bp *BindingPattern) TokenLiteral() string { return bp.Token.Literal }
+func (bp *BindingPattern) String() string {
+	if bp.Default != nil {
+		return bp.Name.String() + " = " + bp.Default.String()
+	}
+	return bp.Name.String()
+}
+
+// LiteralPattern matches values equal to a literal such as 0, -1.5,
+// "text" or true.
+type LiteralPattern struct {
+	Token token.Token
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+
+// ArrayPattern matches arrays element by element. Without Rest the array
+// must have exactly len(Elements) elements; with it, at least that many,
+// and Rest is matched against an array of the remaining ones.
+type ArrayPattern struct {
+	Token    token.Token
+	Elements []Pattern
+	Rest     Pattern
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern matches hashes that have every one of Keys, with the value
+// of each matching the corresponding pattern in Values. Other keys are
+// ignored. A key whose pattern has a default may be missing.
+type HashPattern struct {
+	Token  token.Token
+	Keys   []Expression
+	Values []Pattern
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for i, key := range hp.Keys {
+		if isShorthand(key, hp.Values[i]) {
+			pairs = append(pairs, hp.Values[i].String())
+			continue
+		}
+		pairs = append(pairs, key.String()+": "+hp.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// isShorthand reports whether key and value were written as just a name,
+// as in {name} or {name = default}.
+func isShorthand(key Expression, value Pattern) bool {
+	str, ok := key.(*StringLiteral)
+	if !ok || str.Token.Type != token.IDENT {
+		return false
+	}
+	binding, ok := value.(*BindingPattern)
+	return ok && binding.Name.Value == str.Value
+}
@@ -0,0 +1,10 @@
+package ast
+
+type ModifierFunc func(Node) Node
+
+// Modify walks the tree rooted at node depth-first, replacing every child
+// with the result of calling modifier on it, and finally returns
+// modifier(node). It is the form of Rewrite used by macro expansion.
+func Modify(node Node, modifier ModifierFunc) Node {
+	return Rewrite(node, modifier)
+}
@@ -0,0 +1,46 @@
+package ast
+
+import "monkey/token"
+
+// PipeExpr is Left |> Right. If Right is a call, Left is passed to it in
+// place of the placeholder _ among its arguments, or before them if there
+// is none; otherwise Right is called with Left as its only argument.
+type PipeExpr struct {
+	Token token.Token
+	Left  Expression
+	Right Expression
+}
+
+func (pe *PipeExpr) expressionNode()      {}
+func (pe *PipeExpr) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PipeExpr) String() string {
+	return "(" + pe.Left.String() + " |> " + pe.Right.String() + ")"
+}
+
+// Call returns the call pe stands for, with Left among its arguments.
+func (pe *PipeExpr) Call() *CallExpression {
+	call, ok := pe.Right.(*CallExpression)
+	if !ok {
+		return &CallExpression{Token: pe.Token, Function: pe.Right, Arguments: []Expression{pe.Left}}
+	}
+
+	args := []Expression{}
+	placed := false
+	for _, arg := range call.Arguments {
+		if IsPlaceholder(arg) {
+			arg, placed = pe.Left, true
+		}
+		args = append(args, arg)
+	}
+	if !placed {
+		args = append([]Expression{pe.Left}, args...)
+	}
+	return &CallExpression{Token: call.Token, Function: call.Function, Arguments: args}
+}
+
+// IsPlaceholder reports whether arg is the placeholder _, which stands for
+// the piped value among the arguments of a call on the right of |>.
+func IsPlaceholder(arg Expression) bool {
+	ident, ok := arg.(*Identifier)
+	return ok && ident.Value == "_"
+}
@@ -0,0 +1,78 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+	"strings"
+)
+
+// StructStatement is struct Name { Fields }, or equivalently type Name {
+// Fields }. It binds Name to a constructor that takes the fields in order
+// or by name.
+type StructStatement struct {
+	Token  token.Token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	out.WriteString(ss.TokenLiteral() + " ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// ImplStatement is impl Target { fn name(params) { body } ... }, which
+// adds the methods Names[i]: Methods[i] to the struct Target. A method
+// receives the instance it is called on as its first parameter.
+type ImplStatement struct {
+	Token   token.Token
+	Target  *Identifier
+	Names   []*Identifier
+	Methods []*FunctionLiteral
+}
+
+func (is *ImplStatement) statementNode()       {}
+func (is *ImplStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImplStatement) String() string {
+	var out bytes.Buffer
+
+	methods := []string{}
+	for i, m := range is.Methods {
+		methods = append(methods, "fn "+is.Names[i].String()+strings.TrimPrefix(m.String(), m.TokenLiteral()))
+	}
+
+	out.WriteString("impl ")
+	out.WriteString(is.Target.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(methods, " "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// AssignStatement is Target = Value. Target is a field access such as
+// p.x; Token is the =.
+type AssignStatement struct {
+	Token  token.Token
+	Target Expression
+	Value  Expression
+}
+
+func (as *AssignStatement) statementNode()       {}
+func (as *AssignStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignStatement) String() string {
+	return as.Target.String() + " = " + as.Value.String() + ";"
+}
@@ -0,0 +1,77 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+	"strings"
+)
+
+// SpawnExpr is spawn Call, which runs a function in a task of its own.
+// Call is either a call expression, whose function and arguments are
+// evaluated before the task starts, or an expression giving a function
+// to call without arguments.
+type SpawnExpr struct {
+	Token token.Token
+	Call  Expression
+}
+
+func (se *SpawnExpr) expressionNode()      {}
+func (se *SpawnExpr) TokenLiteral() string { return se.Token.Literal }
+func (se *SpawnExpr) String() string       { return "spawn " + se.Call.String() }
+
+// SelectExpression is select { case, ... }. It waits until the channel
+// operation of one of its cases can go ahead, performs it and evaluates
+// that case's body. A default case makes it not wait at all.
+type SelectExpression struct {
+	Token token.Token
+	Cases []*SelectCase
+}
+
+func (se *SelectExpression) expressionNode()      {}
+func (se *SelectExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SelectExpression) String() string {
+	var out bytes.Buffer
+
+	cases := []string{}
+	for _, c := range se.Cases {
+		cases = append(cases, c.String())
+	}
+
+	out.WriteString("select { ")
+	out.WriteString(strings.Join(cases, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// SelectCase is one case of a select: Name = Channel.recv() => Body,
+// Channel.send(Value) => Body, or the default case _ => Body, which has
+// a nil Channel. Name is optional and Value is nil for receives.
+type SelectCase struct {
+	Token   token.Token
+	Name    *Identifier
+	Channel Expression
+	Value   Expression
+	Body    *BlockStatement
+}
+
+func (sc *SelectCase) TokenLiteral() string { return sc.Token.Literal }
+func (sc *SelectCase) String() string {
+	var out bytes.Buffer
+
+	switch {
+	case sc.Channel == nil:
+		out.WriteString("_")
+	case sc.Value != nil:
+		out.WriteString(sc.Channel.String() + ".send(" + sc.Value.String() + ")")
+	default:
+		if sc.Name != nil {
+			out.WriteString(sc.Name.String() + " = ")
+		}
+		out.WriteString(sc.Channel.String() + ".recv()")
+	}
+	out.WriteString(" => ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
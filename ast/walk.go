@@ -0,0 +1,642 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Pattern != nil {
+			Walk(v, n.Pattern)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			Walk(v, n.ReturnValue)
+		}
+	case *StructStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkIdentifiers(v, n.Fields)
+	case *ImplStatement:
+		if n.Target != nil {
+			Walk(v, n.Target)
+		}
+		for i, m := range n.Methods {
+			if n.Names[i] != nil {
+				Walk(v, n.Names[i])
+			}
+			if m != nil {
+				Walk(v, m)
+			}
+		}
+	case *EnumStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		for _, variant := range n.Variants {
+			if variant != nil {
+				Walk(v, variant)
+			}
+		}
+	case *EnumVariant:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkIdentifiers(v, n.Fields)
+	case *AssignStatement:
+		if n.Target != nil {
+			Walk(v, n.Target)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral, *NamedType, *WildcardPattern:
+		// nothing to do
+	case *Identifier:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *PrefixExpression:
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+	case *InfixExpression:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+	case *IfExpression:
+		if n.Condition != nil {
+			Walk(v, n.Condition)
+		}
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *FunctionLiteral:
+		walkIdentifiers(v, n.Parameters)
+		walkExpressions(v, n.Defaults)
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *MacroLiteral:
+		walkIdentifiers(v, n.Parameters)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *CallExpression:
+		if n.Function != nil {
+			Walk(v, n.Function)
+		}
+		walkExpressions(v, n.Arguments)
+	case *SpreadExpr:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *NamedArgument:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+	case *HashLiteral:
+		for i, key := range n.Keys {
+			if key != nil {
+				Walk(v, key)
+			}
+			if n.Values[i] != nil {
+				Walk(v, n.Values[i])
+			}
+		}
+	case *IndexExpr:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Index != nil {
+			Walk(v, n.Index)
+		}
+	case *MemberExpr:
+		if n.Object != nil {
+			Walk(v, n.Object)
+		}
+		if n.Property != nil {
+			Walk(v, n.Property)
+		}
+	case *SliceExpr:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Start != nil {
+			Walk(v, n.Start)
+		}
+		if n.End != nil {
+			Walk(v, n.End)
+		}
+	case *PipeExpr:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+	case *RangeExpr:
+		if n.Start != nil {
+			Walk(v, n.Start)
+		}
+		if n.End != nil {
+			Walk(v, n.End)
+		}
+	case *SpawnExpr:
+		if n.Call != nil {
+			Walk(v, n.Call)
+		}
+	case *YieldExpr:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ForStatement:
+		if n.Target != nil {
+			Walk(v, n.Target)
+		}
+		if n.Iterable != nil {
+			Walk(v, n.Iterable)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *SelectExpression:
+		for _, c := range n.Cases {
+			if c != nil {
+				Walk(v, c)
+			}
+		}
+	case *SelectCase:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Channel != nil {
+			Walk(v, n.Channel)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *MatchExpression:
+		if n.Subject != nil {
+			Walk(v, n.Subject)
+		}
+		for _, arm := range n.Arms {
+			if arm != nil {
+				Walk(v, arm)
+			}
+		}
+	case *MatchArm:
+		if n.Pattern != nil {
+			Walk(v, n.Pattern)
+		}
+		if n.Guard != nil {
+			Walk(v, n.Guard)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *BindingPattern:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+	case *LiteralPattern:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ArrayPattern:
+		walkPatterns(v, n.Elements)
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+	case *VariantPattern:
+		if n.Enum != nil {
+			Walk(v, n.Enum)
+		}
+		if n.Variant != nil {
+			Walk(v, n.Variant)
+		}
+		walkPatterns(v, n.Args)
+	case *HashPattern:
+		for i, key := range n.Keys {
+			if key != nil {
+				Walk(v, key)
+			}
+			if n.Values[i] != nil {
+				Walk(v, n.Values[i])
+			}
+		}
+	case *ArrayType:
+		if n.Element != nil {
+			Walk(v, n.Element)
+		}
+	case *FunctionType:
+		for _, p := range n.Parameters {
+			if p != nil {
+				Walk(v, p)
+			}
+		}
+		if n.Return != nil {
+			Walk(v, n.Return)
+		}
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, s := range list {
+		if s != nil {
+			Walk(v, s)
+		}
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, e := range list {
+		if e != nil {
+			Walk(v, e)
+		}
+	}
+}
+
+func walkPatterns(v Visitor, list []Pattern) {
+	for _, p := range list {
+		if p != nil {
+			Walk(v, p)
+		}
+	}
+}
+
+func walkIdentifiers(v Visitor, list []*Identifier) {
+	for _, i := range list {
+		if i != nil {
+			Walk(v, i)
+		}
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite traverses an AST in depth-first order and replaces every non-nil
+// child of a node with the result of calling f on it, after that child's
+// own children have been rewritten. It returns f(node). Returning the
+// argument unchanged from f leaves the node in place; a result of the
+// wrong kind for its slot (e.g. a Statement where an Expression is
+// expected) clears the slot.
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		rewriteStatements(n.Statements, f)
+	case *LetStatement:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		if n.Pattern != nil {
+			n.Pattern, _ = Rewrite(n.Pattern, f).(Pattern)
+		}
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			n.ReturnValue, _ = Rewrite(n.ReturnValue, f).(Expression)
+		}
+	case *StructStatement:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		rewriteIdentifiers(n.Fields, f)
+	case *ImplStatement:
+		if n.Target != nil {
+			n.Target, _ = Rewrite(n.Target, f).(*Identifier)
+		}
+		rewriteIdentifiers(n.Names, f)
+		for i, m := range n.Methods {
+			if m != nil {
+				n.Methods[i], _ = Rewrite(m, f).(*FunctionLiteral)
+			}
+		}
+	case *EnumStatement:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		for i, variant := range n.Variants {
+			if variant != nil {
+				n.Variants[i], _ = Rewrite(variant, f).(*EnumVariant)
+			}
+		}
+	case *EnumVariant:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		rewriteIdentifiers(n.Fields, f)
+	case *AssignStatement:
+		if n.Target != nil {
+			n.Target, _ = Rewrite(n.Target, f).(Expression)
+		}
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			n.Expression, _ = Rewrite(n.Expression, f).(Expression)
+		}
+	case *BlockStatement:
+		rewriteStatements(n.Statements, f)
+	case *PrefixExpression:
+		if n.Right != nil {
+			n.Right, _ = Rewrite(n.Right, f).(Expression)
+		}
+	case *InfixExpression:
+		if n.Left != nil {
+			n.Left, _ = Rewrite(n.Left, f).(Expression)
+		}
+		if n.Right != nil {
+			n.Right, _ = Rewrite(n.Right, f).(Expression)
+		}
+	case *IfExpression:
+		if n.Condition != nil {
+			n.Condition, _ = Rewrite(n.Condition, f).(Expression)
+		}
+		if n.Consequence != nil {
+			n.Consequence, _ = Rewrite(n.Consequence, f).(*BlockStatement)
+		}
+		if n.Alternative != nil {
+			n.Alternative, _ = Rewrite(n.Alternative, f).(*BlockStatement)
+		}
+	case *Identifier:
+		if n.Type != nil {
+			n.Type, _ = Rewrite(n.Type, f).(TypeExpr)
+		}
+	case *FunctionLiteral:
+		rewriteIdentifiers(n.Parameters, f)
+		rewriteExpressions(n.Defaults, f)
+		if n.ReturnType != nil {
+			n.ReturnType, _ = Rewrite(n.ReturnType, f).(TypeExpr)
+		}
+		if n.Body != nil {
+			n.Body, _ = Rewrite(n.Body, f).(*BlockStatement)
+		}
+	case *MacroLiteral:
+		rewriteIdentifiers(n.Parameters, f)
+		if n.Body != nil {
+			n.Body, _ = Rewrite(n.Body, f).(*BlockStatement)
+		}
+	case *CallExpression:
+		if n.Function != nil {
+			n.Function, _ = Rewrite(n.Function, f).(Expression)
+		}
+		rewriteExpressions(n.Arguments, f)
+	case *SpreadExpr:
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+	case *NamedArgument:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+	case *ArrayLiteral:
+		rewriteExpressions(n.Elements, f)
+	case *HashLiteral:
+		rewriteExpressions(n.Keys, f)
+		rewriteExpressions(n.Values, f)
+	case *IndexExpr:
+		if n.Left != nil {
+			n.Left, _ = Rewrite(n.Left, f).(Expression)
+		}
+		if n.Index != nil {
+			n.Index, _ = Rewrite(n.Index, f).(Expression)
+		}
+	case *MemberExpr:
+		if n.Object != nil {
+			n.Object, _ = Rewrite(n.Object, f).(Expression)
+		}
+		if n.Property != nil {
+			n.Property, _ = Rewrite(n.Property, f).(*Identifier)
+		}
+	case *SliceExpr:
+		if n.Left != nil {
+			n.Left, _ = Rewrite(n.Left, f).(Expression)
+		}
+		if n.Start != nil {
+			n.Start, _ = Rewrite(n.Start, f).(Expression)
+		}
+		if n.End != nil {
+			n.End, _ = Rewrite(n.End, f).(Expression)
+		}
+	case *PipeExpr:
+		if n.Left != nil {
+			n.Left, _ = Rewrite(n.Left, f).(Expression)
+		}
+		if n.Right != nil {
+			n.Right, _ = Rewrite(n.Right, f).(Expression)
+		}
+	case *RangeExpr:
+		if n.Start != nil {
+			n.Start, _ = Rewrite(n.Start, f).(Expression)
+		}
+		if n.End != nil {
+			n.End, _ = Rewrite(n.End, f).(Expression)
+		}
+	case *SpawnExpr:
+		if n.Call != nil {
+			n.Call, _ = Rewrite(n.Call, f).(Expression)
+		}
+	case *YieldExpr:
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+	case *ForStatement:
+		if n.Target != nil {
+			n.Target, _ = Rewrite(n.Target, f).(Pattern)
+		}
+		if n.Iterable != nil {
+			n.Iterable, _ = Rewrite(n.Iterable, f).(Expression)
+		}
+		if n.Body != nil {
+			n.Body, _ = Rewrite(n.Body, f).(*BlockStatement)
+		}
+	case *SelectExpression:
+		for i, c := range n.Cases {
+			if c != nil {
+				n.Cases[i], _ = Rewrite(c, f).(*SelectCase)
+			}
+		}
+	case *SelectCase:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		if n.Channel != nil {
+			n.Channel, _ = Rewrite(n.Channel, f).(Expression)
+		}
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+		if n.Body != nil {
+			n.Body, _ = Rewrite(n.Body, f).(*BlockStatement)
+		}
+	case *MatchExpression:
+		if n.Subject != nil {
+			n.Subject, _ = Rewrite(n.Subject, f).(Expression)
+		}
+		for i, arm := range n.Arms {
+			if arm != nil {
+				n.Arms[i], _ = Rewrite(arm, f).(*MatchArm)
+			}
+		}
+	case *MatchArm:
+		if n.Pattern != nil {
+			n.Pattern, _ = Rewrite(n.Pattern, f).(Pattern)
+		}
+		if n.Guard != nil {
+			n.Guard, _ = Rewrite(n.Guard, f).(Expression)
+		}
+		if n.Body != nil {
+			n.Body, _ = Rewrite(n.Body, f).(*BlockStatement)
+		}
+	case *BindingPattern:
+		if n.Name != nil {
+			n.Name, _ = Rewrite(n.Name, f).(*Identifier)
+		}
+		if n.Default != nil {
+			n.Default, _ = Rewrite(n.Default, f).(Expression)
+		}
+	case *LiteralPattern:
+		if n.Value != nil {
+			n.Value, _ = Rewrite(n.Value, f).(Expression)
+		}
+	case *ArrayPattern:
+		rewritePatterns(n.Elements, f)
+		if n.Rest != nil {
+			n.Rest, _ = Rewrite(n.Rest, f).(Pattern)
+		}
+	case *VariantPattern:
+		if n.Enum != nil {
+			n.Enum, _ = Rewrite(n.Enum, f).(*Identifier)
+		}
+		if n.Variant != nil {
+			n.Variant, _ = Rewrite(n.Variant, f).(*Identifier)
+		}
+		rewritePatterns(n.Args, f)
+	case *HashPattern:
+		rewriteExpressions(n.Keys, f)
+		rewritePatterns(n.Values, f)
+	case *ArrayType:
+		if n.Element != nil {
+			n.Element, _ = Rewrite(n.Element, f).(TypeExpr)
+		}
+	case *FunctionType:
+		for i, p := range n.Parameters {
+			if p != nil {
+				n.Parameters[i], _ = Rewrite(p, f).(TypeExpr)
+			}
+		}
+		if n.Return != nil {
+			n.Return, _ = Rewrite(n.Return, f).(TypeExpr)
+		}
+	}
+
+	return f(node)
+}
+
+func rewriteStatements(list []Statement, f func(Node) Node) {
+	for i, s := range list {
+		if s != nil {
+			list[i], _ = Rewrite(s, f).(Statement)
+		}
+	}
+}
+
+func rewriteExpressions(list []Expression, f func(Node) Node) {
+	for i, e := range list {
+		if e != nil {
+			list[i], _ = Rewrite(e, f).(Expression)
+		}
+	}
+}
+
+func rewritePatterns(list []Pattern, f func(Node) Node) {
+	for i, p := range list {
+		if p != nil {
+			list[i], _ = Rewrite(p, f).(Pattern)
+		}
+	}
+}
+
+func rewriteIdentifiers(list []*Identifier, f func(Node) Node) {
+	for i, ident := range list {
+		if ident != nil {
+			list[i], _ = Rewrite(ident, f).(*Identifier)
+		}
+	}
+}
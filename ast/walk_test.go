@@ -0,0 +1,146 @@
+package ast
+
+import (
+	"monkey/token"
+	"reflect"
+	"testing"
+)
+
+func ident(name string) *Identifier {
+	return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+}
+
+func exprStmt(e Expression) *ExpressionStatement {
+	return &ExpressionStatement{Expression: e}
+}
+
+func TestInspect(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Name: ident("f"),
+				Value: &FunctionLiteral{
+					Parameters: []*Identifier{ident("a"), ident("b")},
+					Body: &BlockStatement{
+						Statements: []Statement{
+							exprStmt(&IfExpression{
+								Condition:   ident("a"),
+								Consequence: &BlockStatement{Statements: []Statement{exprStmt(ident("b"))}},
+								Alternative: &BlockStatement{Statements: []Statement{exprStmt(ident("c"))}},
+							}),
+						},
+					},
+				},
+			},
+			exprStmt(&IndexExpr{
+				Left:  &CallExpression{Function: ident("f"), Arguments: []Expression{ident("d")}},
+				Index: ident("e"),
+			}),
+		},
+	}
+
+	var names []string
+	Inspect(program, func(n Node) bool {
+		if i, ok := n.(*Identifier); ok {
+			names = append(names, i.Value)
+		}
+		return true
+	})
+
+	expected := []string{"f", "a", "b", "a", "b", "c", "f", "d", "e"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("visited identifiers wrong. want=%v, got=%v", expected, names)
+	}
+}
+
+func TestInspectPrune(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			exprStmt(&FunctionLiteral{
+				Parameters: []*Identifier{ident("x")},
+				Body:       &BlockStatement{Statements: []Statement{exprStmt(ident("y"))}},
+			}),
+			exprStmt(ident("z")),
+		},
+	}
+
+	var names []string
+	Inspect(program, func(n Node) bool {
+		switch n := n.(type) {
+		case *FunctionLiteral:
+			return false
+		case *Identifier:
+			names = append(names, n.Value)
+		}
+		return true
+	})
+
+	if !reflect.DeepEqual(names, []string{"z"}) {
+		t.Errorf("function literal was not pruned. got=%v", names)
+	}
+}
+
+type countingVisitor struct {
+	enter, leave int
+}
+
+func (c *countingVisitor) Visit(n Node) Visitor {
+	if n == nil {
+		c.leave++
+	} else {
+		c.enter++
+	}
+	return c
+}
+
+func TestWalkVisitsNilAfterChildren(t *testing.T) {
+	node := &InfixExpression{Left: ident("a"), Operator: "+", Right: ident("b")}
+
+	v := &countingVisitor{}
+	Walk(v, node)
+
+	if v.enter != 3 || v.leave != 3 {
+		t.Errorf("wrong visit counts. enter=%d, leave=%d", v.enter, v.leave)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			exprStmt(&IfExpression{
+				Condition:   ident("a"),
+				Consequence: &BlockStatement{Statements: []Statement{exprStmt(ident("a"))}},
+				Alternative: &BlockStatement{Statements: []Statement{exprStmt(ident("a"))}},
+			}),
+			exprStmt(&FunctionLiteral{
+				Parameters: []*Identifier{ident("a")},
+				Body:       &BlockStatement{Statements: []Statement{exprStmt(ident("a"))}},
+			}),
+			exprStmt(&IndexExpr{Left: ident("a"), Index: ident("a")}),
+		},
+	}
+
+	rewritten := Rewrite(program, func(n Node) Node {
+		if i, ok := n.(*Identifier); ok && i.Value == "a" {
+			return ident("b")
+		}
+		return n
+	})
+
+	var names []string
+	Inspect(rewritten, func(n Node) bool {
+		if i, ok := n.(*Identifier); ok {
+			names = append(names, i.Value)
+		}
+		return true
+	})
+
+	if len(names) != 7 {
+		t.Fatalf("wrong number of identifiers. got=%d", len(names))
+	}
+	for _, name := range names {
+		if name != "b" {
+			t.Errorf("identifier not rewritten. got=%q", name)
+		}
+	}
+}
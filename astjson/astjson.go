@@ -0,0 +1,344 @@
+// Package astjson converts Monkey syntax trees to and from JSON so that
+// tools written in other languages can consume the parser's output.
+//
+// Every node is encoded as an object carrying its kind (the Go type name
+// in package ast), its token, the source span it covers and one member
+// per field of the node, named after the field in lower camel case:
+//
+//	{"kind": "Identifier", "token": {...}, "span": {...}, "value": "x"}
+//
+// Decoding ignores spans; they are derived from the tokens.
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Position is a 1-based line and column in the source.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Span is the half-open range of source a node was parsed from.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+var kinds = map[string]func() ast.Node{
+	"Program":             func() ast.Node { return &ast.Program{} },
+	"LetStatement":        func() ast.Node { return &ast.LetStatement{} },
+	"StructStatement":     func() ast.Node { return &ast.StructStatement{} },
+	"ImplStatement":       func() ast.Node { return &ast.ImplStatement{} },
+	"EnumStatement":       func() ast.Node { return &ast.EnumStatement{} },
+	"EnumVariant":         func() ast.Node { return &ast.EnumVariant{} },
+	"AssignStatement":     func() ast.Node { return &ast.AssignStatement{} },
+	"ReturnStatement":     func() ast.Node { return &ast.ReturnStatement{} },
+	"ExpressionStatement": func() ast.Node { return &ast.ExpressionStatement{} },
+	"BlockStatement":      func() ast.Node { return &ast.BlockStatement{} },
+	"Identifier":          func() ast.Node { return &ast.Identifier{} },
+	"IntegerLiteral":      func() ast.Node { return &ast.IntegerLiteral{} },
+	"FloatLiteral":        func() ast.Node { return &ast.FloatLiteral{} },
+	"Boolean":             func() ast.Node { return &ast.Boolean{} },
+	"StringLiteral":       func() ast.Node { return &ast.StringLiteral{} },
+	"SpreadExpr":          func() ast.Node { return &ast.SpreadExpr{} },
+	"NamedArgument":       func() ast.Node { return &ast.NamedArgument{} },
+	"PrefixExpression":    func() ast.Node { return &ast.PrefixExpression{} },
+	"InfixExpression":     func() ast.Node { return &ast.InfixExpression{} },
+	"IfExpression":        func() ast.Node { return &ast.IfExpression{} },
+	"FunctionLiteral":     func() ast.Node { return &ast.FunctionLiteral{} },
+	"MacroLiteral":        func() ast.Node { return &ast.MacroLiteral{} },
+	"CallExpression":      func() ast.Node { return &ast.CallExpression{} },
+	"ArrayLiteral":        func() ast.Node { return &ast.ArrayLiteral{} },
+	"IndexExpr":           func() ast.Node { return &ast.IndexExpr{} },
+	"MemberExpr":          func() ast.Node { return &ast.MemberExpr{} },
+	"SliceExpr":           func() ast.Node { return &ast.SliceExpr{} },
+	"RangeExpr":           func() ast.Node { return &ast.RangeExpr{} },
+	"PipeExpr":            func() ast.Node { return &ast.PipeExpr{} },
+	"HashLiteral":         func() ast.Node { return &ast.HashLiteral{} },
+	"SpawnExpr":           func() ast.Node { return &ast.SpawnExpr{} },
+	"YieldExpr":           func() ast.Node { return &ast.YieldExpr{} },
+	"ForStatement":        func() ast.Node { return &ast.ForStatement{} },
+	"SelectExpression":    func() ast.Node { return &ast.SelectExpression{} },
+	"SelectCase":          func() ast.Node { return &ast.SelectCase{} },
+	"MatchExpression":     func() ast.Node { return &ast.MatchExpression{} },
+	"MatchArm":            func() ast.Node { return &ast.MatchArm{} },
+	"WildcardPattern":     func() ast.Node { return &ast.WildcardPattern{} },
+	"BindingPattern":      func() ast.Node { return &ast.BindingPattern{} },
+	"LiteralPattern":      func() ast.Node { return &ast.LiteralPattern{} },
+	"ArrayPattern":        func() ast.Node { return &ast.ArrayPattern{} },
+	"HashPattern":         func() ast.Node { return &ast.HashPattern{} },
+	"VariantPattern":      func() ast.Node { return &ast.VariantPattern{} },
+	"NamedType":           func() ast.Node { return &ast.NamedType{} },
+	"ArrayType":           func() ast.Node { return &ast.ArrayType{} },
+	"FunctionType":        func() ast.Node { return &ast.FunctionType{} },
+}
+
+var (
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// MaxDepth is the deepest nesting of JSON objects and arrays that
+// encoding/json accepts when decoding. Every node adds a level, and each
+// list of nodes another, so a long chain of left-associative operators
+// such as 1 + 1 + ... + 1 reaches it at a few thousand terms.
+const MaxDepth = 10000
+
+// Marshal returns the JSON encoding of program. It fails if the encoding
+// would nest deeper than MaxDepth, since it could not be decoded.
+func Marshal(program *ast.Program) ([]byte, error) {
+	out, err := encode(program)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(out)
+}
+
+// MarshalIndent is like Marshal but indents the output.
+func MarshalIndent(program *ast.Program, prefix, indent string) ([]byte, error) {
+	out, err := encode(program)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(out, prefix, indent)
+}
+
+// Unmarshal decodes a program previously encoded with Marshal.
+func Unmarshal(data []byte) (*ast.Program, error) {
+	node, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	program, ok := node.(*ast.Program)
+	if !ok {
+		return nil, fmt.Errorf("astjson: top-level node is %T, not *ast.Program", node)
+	}
+
+	return program, nil
+}
+
+func encode(program *ast.Program) (interface{}, error) {
+	e := &encoder{}
+	out, _ := e.node(program)
+	if e.tooDeep {
+		return nil, fmt.Errorf("astjson: program nests deeper than %d levels", MaxDepth)
+	}
+	return out, nil
+}
+
+// encoder tracks how deeply the JSON being built is nested.
+type encoder struct {
+	depth   int
+	tooDeep bool
+}
+
+// node encodes node and returns the span it covers, which is computed
+// from the spans of its children as they are encoded.
+func (e *encoder) node(node ast.Node) (interface{}, Span) {
+	v := reflect.ValueOf(node)
+	if node == nil || v.IsNil() || e.enter() {
+		return nil, Span{}
+	}
+	defer e.leave()
+
+	elem := v.Elem()
+	typ := elem.Type()
+
+	span := tokenSpan(node)
+	out := map[string]interface{}{
+		"kind": typ.Name(),
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value, child := e.value(elem.Field(i))
+		out[fieldName(field.Name)] = value
+		span = cover(span, child)
+	}
+
+	out["span"] = span
+	return out, span
+}
+
+func (e *encoder) value(v reflect.Value) (interface{}, Span) {
+	switch {
+	case v.Type().Implements(nodeType):
+		if v.IsNil() {
+			return nil, Span{}
+		}
+		return e.node(v.Interface().(ast.Node))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Implements(nodeType):
+		if v.IsNil() || e.enter() {
+			return nil, Span{}
+		}
+		defer e.leave()
+
+		var span Span
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			var child Span
+			list[i], child = e.value(v.Index(i))
+			span = cover(span, child)
+		}
+		return list, span
+	default:
+		return v.Interface(), Span{}
+	}
+}
+
+// enter descends a level, reporting whether that is too deep.
+func (e *encoder) enter() bool {
+	e.depth++
+	if e.depth > MaxDepth {
+		e.tooDeep = true
+	}
+	return e.tooDeep
+}
+
+func (e *encoder) leave() {
+	e.depth--
+}
+
+func decodeNode(data []byte) (ast.Node, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, nil
+	}
+
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil {
+		return nil, fmt.Errorf("astjson: node without kind: %s", data)
+	}
+
+	newNode, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("astjson: unknown node kind %q", kind)
+	}
+
+	node := newNode()
+	elem := reflect.ValueOf(node).Elem()
+	typ := elem.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		raw, ok := fields[fieldName(field.Name)]
+		if !ok {
+			continue
+		}
+
+		if err := decodeValue(raw, elem.Field(i)); err != nil {
+			return nil, fmt.Errorf("astjson: %s.%s: %w", kind, field.Name, err)
+		}
+	}
+
+	return node, nil
+}
+
+func decodeValue(raw json.RawMessage, v reflect.Value) error {
+	switch {
+	case v.Type().Implements(nodeType):
+		node, err := decodeNode(raw)
+		if err != nil || node == nil {
+			return err
+		}
+		nv := reflect.ValueOf(node)
+		if !nv.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("%T is not assignable to %s", node, v.Type())
+		}
+		v.Set(nv)
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Implements(nodeType):
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+		if list == nil {
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeValue(item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	default:
+		return json.Unmarshal(raw, v.Addr().Interface())
+	}
+}
+
+// tokenSpan returns the range covered by the token of node itself. Nodes
+// built without position information get a zero Span.
+func tokenSpan(node ast.Node) Span {
+	tok, ok := tokenOf(node)
+	if !ok || tok.Line == 0 {
+		return Span{}
+	}
+	return Span{
+		Start: Position{Line: tok.Line, Column: tok.Column},
+		End:   Position{Line: tok.Line, Column: tok.Column + tokenWidth(tok)},
+	}
+}
+
+// cover returns the smallest span covering a and b, ignoring zero spans.
+func cover(a, b Span) Span {
+	if a.Start.Line == 0 {
+		return b
+	}
+	if b.Start.Line == 0 {
+		return a
+	}
+	if before(b.Start, a.Start) {
+		a.Start = b.Start
+	}
+	if before(a.End, b.End) {
+		a.End = b.End
+	}
+	return a
+}
+
+func tokenOf(node ast.Node) (token.Token, bool) {
+	v := reflect.ValueOf(node).Elem()
+	f := v.FieldByName("Token")
+	if !f.IsValid() || f.Type() != tokenType {
+		return token.Token{}, false
+	}
+	return f.Interface().(token.Token), true
+}
+
+func tokenWidth(tok token.Token) int {
+	width := utf8.RuneCountInString(tok.Literal)
+	if tok.Type == token.STRING {
+		width += 2
+	}
+	return width
+}
+
+func before(a, b Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+func fieldName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,176 @@
+package astjson
+
+import (
+	"encoding/json"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		`let x = 5;`,
+		`return add(1, 2 * 3);`,
+		`-a * !b`,
+		`"hello" + " " + "world"`,
+		`if (x < y) { x } else { y }`,
+		`if (true) { return 1; }`,
+		`let f = fn(a, b) { let c = a + b; c * 2 }; f(1, 2)`,
+		`[1, 2, 3][1 + 1]`,
+		`let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) };`,
+		`fn() {}`,
+		`{"a": 1, true: [2], 3: {}}["a"]`,
+		`let f: fn(int, [string]) -> bool = fn(a: int, b: [string]) -> bool { true };`,
+		`math.sqrt(2.5) + "abc"[1:]`,
+		`100000000000000000000 - 1`,
+		`let f = fn(a, b = 2, ...rest) { a }; f(1, ...xs, c: 3);`,
+		`struct Point { x, y } let p = Point(1, y: 2); p.x = p.y;`,
+		`type P { x } impl P { fn get(self, n = 1) { self.x + n } } P(1).get()`,
+		`enum R { Ok(v), Err(a, b), None } match (R.Ok(1)) { R.Ok([x, 1]) => x, R.Err(_, b) => b, R.None => 0 }`,
+		`let t = spawn f(1, b: 2); select { v = t.recv() => v, t.send([1]) => 0, _ => { 1 } }`,
+		`let g = fn() { yield 1; return 2 }; for ([i, x] in enumerate(g())) { x }`,
+		`let r = 1..10; for (i in 0..=len(r)) { r[-1] }`,
+		`let f = g >> h; xs |> map(_, f) |> sum`,
+		`let [a, b = 1, ...r] = xs; let {name, pos: {x}} = h;`,
+		`match (x) { [a, ...r] if a > 1 => a, {"k": _} => { 2 }, -1 => 3 }`,
+	}
+
+	for _, input := range tests {
+		program := parse(t, input)
+
+		data, err := Marshal(program)
+		if err != nil {
+			t.Fatalf("Marshal(%q) failed: %s", input, err)
+		}
+
+		decoded, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Unmarshal(%q) failed: %s", input, err)
+		}
+
+		if decoded.String() != program.String() {
+			t.Errorf("round trip changed program. want=%q, got=%q",
+				program.String(), decoded.String())
+		}
+	}
+}
+
+func TestBigIntegerLiteral(t *testing.T) {
+	data, err := Marshal(parse(t, "100000000000000000000"))
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	lit := decoded.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+	if lit.Big == nil || lit.Big.String() != "100000000000000000000" {
+		t.Errorf("wrong Big value. got=%v", lit.Big)
+	}
+}
+
+func TestMarshalShape(t *testing.T) {
+	program := parse(t, "let answer = 42;")
+
+	data, err := Marshal(program)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	var out struct {
+		Kind       string
+		Statements []struct {
+			Kind  string
+			Span  Span
+			Token struct {
+				Type    string
+				Literal string
+			}
+			Name struct {
+				Kind  string
+				Value string
+				Span  Span
+			}
+			Value struct {
+				Kind  string
+				Value int64
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+
+	if out.Kind != "Program" || len(out.Statements) != 1 {
+		t.Fatalf("unexpected program shape: %s", data)
+	}
+
+	let := out.Statements[0]
+	if let.Kind != "LetStatement" || let.Token.Literal != "let" {
+		t.Errorf("wrong statement. got kind=%q token=%q", let.Kind, let.Token.Literal)
+	}
+	if let.Name.Kind != "Identifier" || let.Name.Value != "answer" {
+		t.Errorf("wrong name. got kind=%q value=%q", let.Name.Kind, let.Name.Value)
+	}
+	if let.Value.Kind != "IntegerLiteral" || let.Value.Value != 42 {
+		t.Errorf("wrong value. got kind=%q value=%d", let.Value.Kind, let.Value.Value)
+	}
+
+	wantName := Span{Start: Position{1, 5}, End: Position{1, 11}}
+	if let.Name.Span != wantName {
+		t.Errorf("wrong name span. want=%+v, got=%+v", wantName, let.Name.Span)
+	}
+	wantLet := Span{Start: Position{1, 1}, End: Position{1, 16}}
+	if let.Span != wantLet {
+		t.Errorf("wrong statement span. want=%+v, got=%+v", wantLet, let.Span)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []string{
+		`{"kind": "Nope"}`,
+		`{"statements": []}`,
+		`{"kind": "Identifier", "value": "x"}`,
+		`{"kind": "Program", "statements": [{"kind": "Identifier", "value": "x"}]}`,
+	}
+
+	for _, input := range tests {
+		if _, err := Unmarshal([]byte(input)); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
+
+func TestMarshalDepth(t *testing.T) {
+	chain := func(n int) string {
+		return strings.Repeat("1 + ", n-1) + "1"
+	}
+
+	data, err := Marshal(parse(t, chain(1000)))
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	if _, err := Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	_, err = Marshal(parse(t, chain(12000)))
+	want := "astjson: program nests deeper than 10000 levels"
+	if err == nil || err.Error() != want {
+		t.Errorf("wrong error. want=%q, got=%v", want, err)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"monkey/astjson"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/lint"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"monkey/types"
+	"os"
+	"strings"
+)
+
+func lexCmd(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("lex", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print tokens as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := openSource(flags.Args())
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	l := lexer.NewReader(input)
+	tokens := []token.Token{}
+	for tok := l.NextToken(); ; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	if *asJSON {
+		err = writeJSON(out, tokens)
+	} else {
+		for _, tok := range tokens {
+			fmt.Fprintf(out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+		}
+	}
+	if err == nil && len(l.Errors()) != 0 {
+		err = errors.New("lexical errors:\n\t" + strings.Join(l.Errors(), "\n\t"))
+	}
+	return err
+}
+
+func parseCmd(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := openSource(flags.Args())
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	p := parser.New(lexer.NewReader(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return errors.New("parse errors:\n\t" + strings.Join(p.Errors(), "\n\t"))
+	}
+
+	if *asJSON {
+		data, err := astjson.MarshalIndent(program, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, program.String())
+	return err
+}
+
+func lintCmd(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	enable := flags.String("enable", "", "comma-separated rules to run instead of all")
+	disable := flags.String("disable", "", "comma-separated rules to skip")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("expected at least one FILE argument")
+	}
+
+	cfg := lint.NewConfig()
+	if *enable != "" {
+		for _, r := range lint.AllRules {
+			cfg.Disable(r)
+		}
+		if err := setRules(*enable, cfg.Enable); err != nil {
+			return err
+		}
+	}
+	if err := setRules(*disable, cfg.Disable); err != nil {
+		return err
+	}
+
+	found := 0
+	for _, file := range flags.Args() {
+		input, err := openSource([]string{file})
+		if err != nil {
+			return err
+		}
+
+		p := parser.New(lexer.NewReader(input))
+		program := p.ParseProgram()
+		input.Close()
+		if len(p.Errors()) != 0 {
+			return fmt.Errorf("%s: parse errors:\n\t%s", file, strings.Join(p.Errors(), "\n\t"))
+		}
+
+		for _, d := range lint.Lint(program, cfg) {
+			fmt.Fprintf(out, "%s:%s\n", file, d)
+			found++
+		}
+	}
+
+	if found > 0 {
+		return fmt.Errorf("%d problems found", found)
+	}
+	return nil
+}
+
+func checkCmd(args []string, out io.Writer) error {
+	input, err := openSource(args)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	p := parser.New(lexer.NewReader(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return errors.New("parse errors:\n\t" + strings.Join(p.Errors(), "\n\t"))
+	}
+
+	_, errs := types.Check(program)
+	for _, e := range errs {
+		fmt.Fprintf(out, "%s:%s\n", args[0], e)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d type errors", len(errs))
+	}
+	return nil
+}
+
+func runCmd(args []string, out io.Writer) error {
+	input, err := openSource(args)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	p := parser.New(lexer.NewReader(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return errors.New("parse errors:\n\t" + strings.Join(p.Errors(), "\n\t"))
+	}
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded, err := evaluator.ExpandMacros(program, macroEnv)
+	if err != nil {
+		return err
+	}
+	evaluator.Resolve(expanded)
+
+	result := evaluator.Eval(expanded, object.NewEnvironment())
+	if errObj, ok := result.(*object.Error); ok {
+		return errors.New(strings.TrimPrefix(errObj.Inspect(), "ERROR: "))
+	}
+	if result != nil && result != evaluator.NULL {
+		_, err = fmt.Fprintln(out, evaluator.Inspect(result))
+	}
+	return err
+}
+
+func setRules(list string, set func(lint.Rule)) error {
+	if list == "" {
+		return nil
+	}
+	for _, name := range strings.Split(list, ",") {
+		r, err := lint.ParseRule(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		set(r)
+	}
+	return nil
+}
+
+// openSource opens the single FILE argument, or standard input if it is
+// "-". The caller must close the result.
+func openSource(args []string) (io.ReadCloser, error) {
+	if len(args) != 1 {
+		return nil, errors.New("expected exactly one FILE argument")
+	}
+
+	if args[0] == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(args[0])
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -1,7 +1,13 @@
 package evaluator
 
-import "monkey/object"
+import (
+	"monkey/object"
+	"strings"
+	"unicode/utf8"
+)
 
+// builtins is only written while the package is initialized, so programs
+// evaluated concurrently can read it without locking.
 var builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: func(args ...object.Object) object.Object {
@@ -11,12 +17,43 @@ var builtins = map[string]*object.Builtin{
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Range:
+				return &object.Integer{Value: arg.Len()}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
 		},
 	},
+	"split":       {Fn: builtinSplit},
+	"join":        {Fn: builtinJoin},
+	"trim":        stringMapper("trim", strings.TrimSpace),
+	"upper":       stringMapper("upper", strings.ToUpper),
+	"lower":       stringMapper("lower", strings.ToLower),
+	"replace":     {Fn: builtinReplace},
+	"starts_with": stringPredicate("starts_with", strings.HasPrefix),
+	"ends_with":   stringPredicate("ends_with", strings.HasSuffix),
+	"contains":    {Fn: builtinContains},
+	"index_of":    {Fn: builtinIndexOf},
+	"repeat":      {Fn: builtinRepeat},
+	"pad_left":    padder("pad_left", true),
+	"pad_right":   padder("pad_right", false),
+	"channel":     {Fn: builtinChannel},
+	"await":       {Fn: builtinAwait},
+}
+
+// IsBuiltin reports whether name resolves to a builtin function, a module
+// such as math or one of the quote/unquote forms handled by the evaluator
+// itself.
+func IsBuiltin(name string) bool {
+	if name == "quote" || name == "unquote" {
+		return true
+	}
+	if _, ok := modules[name]; ok {
+		return true
+	}
+	_, ok := builtins[name]
+	return ok
 }
@@ -0,0 +1,126 @@
+package evaluator
+
+import "monkey/object"
+
+// Like the iterator builtins, these call back into the evaluator and so
+// are added in init.
+func init() {
+	builtins["map"] = &object.Builtin{Fn: builtinMap}
+	builtins["filter"] = &object.Builtin{Fn: builtinFilter}
+	builtins["reduce"] = &object.Builtin{Fn: builtinReduce}
+	builtins["sum"] = &object.Builtin{Fn: builtinSum}
+}
+
+// builtinMap returns an array of fn applied to each value of an iterable.
+func builtinMap(args ...object.Object) object.Object {
+	if err := checkArgs("map", args, "", ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("map", args, 0)
+	if err != nil {
+		return err
+	}
+
+	elems := []object.Object{}
+	for {
+		val := src.Next()
+		if val == nil {
+			return &object.Array{Elements: elems}
+		}
+		if isError(val) {
+			return val
+		}
+		mapped := applyFunc(args[1], []object.Object{val}, nil)
+		if isError(mapped) {
+			src.Close()
+			return mapped
+		}
+		elems = append(elems, mapped)
+	}
+}
+
+// builtinFilter returns an array of the values of an iterable for which fn
+// is truthy.
+func builtinFilter(args ...object.Object) object.Object {
+	if err := checkArgs("filter", args, "", ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("filter", args, 0)
+	if err != nil {
+		return err
+	}
+
+	elems := []object.Object{}
+	for {
+		val := src.Next()
+		if val == nil {
+			return &object.Array{Elements: elems}
+		}
+		if isError(val) {
+			return val
+		}
+		keep := applyFunc(args[1], []object.Object{val}, nil)
+		if isError(keep) {
+			src.Close()
+			return keep
+		}
+		if isTruthy(keep) {
+			elems = append(elems, val)
+		}
+	}
+}
+
+// builtinReduce folds the values of an iterable into initial with fn,
+// called as fn(acc, value).
+func builtinReduce(args ...object.Object) object.Object {
+	if err := checkArgs("reduce", args, "", "", ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("reduce", args, 0)
+	if err != nil {
+		return err
+	}
+
+	acc := args[1]
+	for {
+		val := src.Next()
+		if val == nil {
+			return acc
+		}
+		if isError(val) {
+			return val
+		}
+		acc = applyFunc(args[2], []object.Object{acc, val}, nil)
+		if isError(acc) {
+			src.Close()
+			return acc
+		}
+	}
+}
+
+// builtinSum adds up the numbers of an iterable; the sum of none is 0.
+func builtinSum(args ...object.Object) object.Object {
+	if err := checkArgs("sum", args, ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("sum", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var total object.Object = &object.Integer{Value: 0}
+	for {
+		val := src.Next()
+		if val == nil {
+			return total
+		}
+		if isError(val) {
+			return val
+		}
+		if !isNumber(val) {
+			src.Close()
+			return newError("argument 1 to `sum` must contain only numbers, got %s", val.Type())
+		}
+		total = evalInfix("+", total, val)
+	}
+}
@@ -0,0 +1,202 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStringSize bounds the strings repeat and padding build, in bytes
+// for repeat and characters for padding, so that a typo such as
+// repeat("a", 1000000000000) cannot allocate an enormous string.
+const maxStringSize = 1 << 24
+
+// checkArgs verifies the number of arguments to the builtin called name and,
+// where want lists a type other than "", the type of each argument.
+func checkArgs(name string, args []object.Object, want ...object.ObjectType) *object.Error {
+	if len(args) != len(want) {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), len(want))
+	}
+
+	for i, t := range want {
+		if t != "" && args[i].Type() != t {
+			return newError("argument %d to `%s` must be %s, got %s", i+1, name, t, args[i].Type())
+		}
+	}
+
+	return nil
+}
+
+func stringArg(arg object.Object) string {
+	return arg.(*object.String).Value
+}
+
+func builtinSplit(args ...object.Object) object.Object {
+	if err := checkArgs("split", args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+
+	parts := strings.Split(stringArg(args[0]), stringArg(args[1]))
+	elements := make([]object.Object, len(parts))
+	for i, part := range parts {
+		elements[i] = &object.String{Value: part}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func builtinJoin(args ...object.Object) object.Object {
+	if err := checkArgs("join", args, object.ARRAY_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+
+	elements := args[0].(*object.Array).Elements
+	parts := make([]string, len(elements))
+	for i, el := range elements {
+		str, ok := el.(*object.String)
+		if !ok {
+			return newError("argument 1 to `join` must contain only STRING, got %s", el.Type())
+		}
+		parts[i] = str.Value
+	}
+	return &object.String{Value: strings.Join(parts, stringArg(args[1]))}
+}
+
+func stringMapper(name string, fn func(string) string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs(name, args, object.STRING_OBJ); err != nil {
+				return err
+			}
+			return &object.String{Value: fn(stringArg(args[0]))}
+		},
+	}
+}
+
+func stringPredicate(name string, fn func(string, string) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs(name, args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+			return nativeBoolToBoolObj(fn(stringArg(args[0]), stringArg(args[1])))
+		},
+	}
+}
+
+func builtinReplace(args ...object.Object) object.Object {
+	if err := checkArgs("replace", args, object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+
+	replaced := strings.ReplaceAll(stringArg(args[0]), stringArg(args[1]), stringArg(args[2]))
+	return &object.String{Value: replaced}
+}
+
+// builtinContains reports whether a string contains a substring or an
+// array contains an element equal to the second argument.
+func builtinContains(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	switch container := args[0].(type) {
+	case *object.String:
+		if err := checkArgs("contains", args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+			return err
+		}
+		return nativeBoolToBoolObj(strings.Contains(container.Value, stringArg(args[1])))
+	case *object.Array:
+		return nativeBoolToBoolObj(indexOf(container.Elements, args[1]) >= 0)
+	default:
+		return newError("argument to `contains` not supported, got %s", args[0].Type())
+	}
+}
+
+// builtinIndexOf returns the rune index of a substring or the index of an
+// array element, or -1 if there is none.
+func builtinIndexOf(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	switch container := args[0].(type) {
+	case *object.String:
+		if err := checkArgs("index_of", args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+			return err
+		}
+		i := strings.Index(container.Value, stringArg(args[1]))
+		if i >= 0 {
+			i = utf8.RuneCountInString(container.Value[:i])
+		}
+		return &object.Integer{Value: int64(i)}
+	case *object.Array:
+		return &object.Integer{Value: int64(indexOf(container.Elements, args[1]))}
+	default:
+		return newError("argument to `index_of` not supported, got %s", args[0].Type())
+	}
+}
+
+func indexOf(elements []object.Object, obj object.Object) int {
+	for i, el := range elements {
+		if object.Equal(el, obj) {
+			return i
+		}
+	}
+	return -1
+}
+
+func builtinRepeat(args ...object.Object) object.Object {
+	if err := checkArgs("repeat", args, object.STRING_OBJ, object.INTEGER_OBJ); err != nil {
+		return err
+	}
+
+	str := stringArg(args[0])
+	count := args[1].(*object.Integer).Value
+	if count < 0 {
+		return newError("negative repeat count: %d", count)
+	}
+	if str != "" && count > maxStringSize/int64(len(str)) {
+		return newError("repeat count too large: %d", count)
+	}
+	return &object.String{Value: strings.Repeat(str, int(count))}
+}
+
+func padder(name string, left bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			want := []object.ObjectType{object.STRING_OBJ, object.INTEGER_OBJ}
+			if len(args) == 3 {
+				want = append(want, object.STRING_OBJ)
+			}
+			if err := checkArgs(name, args, want...); err != nil {
+				return err
+			}
+
+			str := stringArg(args[0])
+			width := args[1].(*object.Integer).Value
+			pad := " "
+			if len(args) == 3 {
+				pad = stringArg(args[2])
+			}
+			if pad == "" {
+				return newError("padding for `%s` must not be empty", name)
+			}
+
+			if width > maxStringSize {
+				return newError("width for `%s` too large: %d", name, width)
+			}
+
+			missing := int(width) - utf8.RuneCountInString(str)
+			if missing <= 0 {
+				return args[0]
+			}
+
+			times := missing/utf8.RuneCountInString(pad) + 1
+			padRunes := []rune(strings.Repeat(pad, times))[:missing]
+			if left {
+				return &object.String{Value: string(padRunes) + str}
+			}
+			return &object.String{Value: str + string(padRunes)}
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"strings"
+)
+
+func evalEnumStatement(node *ast.EnumStatement, env *object.Environment) object.Object {
+	enum := &object.Enum{Name: node.Name.Value}
+	for _, v := range node.Variants {
+		def := &object.VariantDef{Enum: enum, Name: v.Name.Value}
+		for _, f := range v.Fields {
+			def.Fields = append(def.Fields, f.Value)
+		}
+		enum.Variants = append(enum.Variants, def)
+	}
+	bind(node.Name, enum, env)
+	return nil
+}
+
+// enumMember returns the variant of enum called name: its constructor if
+// it has fields, and otherwise the one value of the variant.
+func enumMember(enum *object.Enum, name string) object.Object {
+	def := enum.Variant(name)
+	if def == nil {
+		return newError("enum %s has no variant %s", enum.Name, name)
+	}
+	if len(def.Fields) == 0 {
+		return &object.Variant{Def: def}
+	}
+	return def
+}
+
+func newVariant(def *object.VariantDef, args []object.Object, named []namedArg) object.Object {
+	if len(named) > 0 {
+		return newError("variant constructors take no named arguments, got %s", named[0].name)
+	}
+	if len(args) != len(def.Fields) {
+		return arityError(len(def.Fields), len(def.Fields), len(args))
+	}
+	return &object.Variant{Def: def, Values: args}
+}
+
+func destructureVariant(pattern *ast.VariantPattern, val object.Object, env *object.Environment) (string, object.Object) {
+	def, err := patternVariant(pattern, env)
+	if err != nil {
+		return "", err
+	}
+
+	v, ok := val.(*object.Variant)
+	if !ok {
+		return fmt.Sprintf("expected %s, got %s", def.Inspect(), val.Type()), nil
+	}
+	if v.Def != def {
+		return fmt.Sprintf("expected %s, got %s", def.Inspect(), v.Def.Inspect()), nil
+	}
+
+	// Without arguments the pattern matches the variant whatever its
+	// fields hold.
+	for i, arg := range pattern.Args {
+		mismatch, err := destructure(arg, v.Values[i], env)
+		if err != nil || mismatch != "" {
+			return mismatch, err
+		}
+	}
+	return "", nil
+}
+
+// patternVariant looks up the variant a pattern names.
+func patternVariant(pattern *ast.VariantPattern, env *object.Environment) (*object.VariantDef, object.Object) {
+	obj := eval(pattern.Enum, env)
+	if isError(obj) {
+		return nil, obj
+	}
+	enum, ok := obj.(*object.Enum)
+	if !ok {
+		return nil, positioned(newError("%s is not an enum", pattern.Enum.Value), pattern.Token)
+	}
+
+	def := enum.Variant(pattern.Variant.Value)
+	if def == nil {
+		return nil, positioned(newError("enum %s has no variant %s", enum.Name, pattern.Variant.Value), pattern.Variant.Token)
+	}
+	if len(pattern.Args) > 0 && len(pattern.Args) != len(def.Fields) {
+		return nil, positioned(newError("wrong number of fields in pattern %s: want=%d, got=%d",
+			pattern, len(def.Fields), len(pattern.Args)), pattern.Token)
+	}
+	return def, nil
+}
+
+// checkExhaustive reports an error unless the arms of a match on a value
+// of enum cover every one of its variants. An arm covers a variant if it
+// has no guard and its pattern matches every value of the variant; a
+// wildcard or a plain name covers them all.
+func checkExhaustive(node *ast.MatchExpression, enum *object.Enum, env *object.Environment) object.Object {
+	covered := map[*object.VariantDef]bool{}
+	for _, arm := range node.Arms {
+		if arm.Guard != nil {
+			continue
+		}
+		switch p := arm.Pattern.(type) {
+		case *ast.WildcardPattern, *ast.BindingPattern:
+			return nil
+		case *ast.VariantPattern:
+			def, err := patternVariant(p, env)
+			if err != nil {
+				return err
+			}
+			if irrefutable(p.Args) {
+				covered[def] = true
+			}
+		}
+	}
+
+	missing := []string{}
+	for _, def := range enum.Variants {
+		if !covered[def] {
+			missing = append(missing, def.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return positioned(newError("match on %s is not exhaustive: missing %s",
+		enum.Name, strings.Join(missing, ", ")), node.Token)
+}
+
+func irrefutable(patterns []ast.Pattern) bool {
+	for _, p := range patterns {
+		switch p.(type) {
+		case *ast.WildcardPattern, *ast.BindingPattern:
+		default:
+			return false
+		}
+	}
+	return true
+}
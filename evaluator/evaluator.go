@@ -2,8 +2,12 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
+	"unicode/utf8"
 )
 
 var (
@@ -12,64 +16,117 @@ var (
 	NULL  = &object.Null{}
 )
 
-func Eval(node ast.Node, env *object.Environment) object.Object {
+// Eval evaluates node in env. It turns a Go panic from a bug in the
+// interpreter into an error rather than crashing the host.
+func Eval(node ast.Node, env *object.Environment) (result object.Object) {
+	defer recoverInternal(&result)
+	return eval(node, env)
+}
+
+// Inspect returns obj.Inspect(), which may run a to_string method, with
+// the same guard against panics as Eval.
+func Inspect(obj object.Object) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = internalError(r).Inspect()
+		}
+	}()
+	return obj.Inspect()
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return eval(node.Expression, env)
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return object.NewInteger(node.Big)
+		}
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBoolObj(node.Value)
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefix(node.Operator, right)
+		return positioned(evalPrefix(node.Operator, right), node.Token)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		left := eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		right := Eval(node.Right, env)
+		right := eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalInfix(node.Operator, left, right)
+		return positioned(evalInfix(node.Operator, left, right), node.Token)
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
 		return evalIf(node, env)
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		val := eval(node.ReturnValue, env)
 		if isError(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.AssignStatement:
+		return evalAssignStatement(node, env)
+	case *ast.ImplStatement:
+		return evalImplStatement(node, env)
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
+		val := eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			return evalDestructuringLet(node, val, env)
+		}
+		bind(node.Name, val, env)
 	case *ast.Identifier:
 		return evalIdent(node, env)
 	case *ast.FunctionLiteral:
-		params := node.Parameters
-		body := node.Body
-		return &object.Function{Params: params, Env: env, Body: body}
+		return &object.Function{
+			Params:    node.Parameters,
+			Defaults:  node.Defaults,
+			Variadic:  node.Variadic,
+			Env:       env,
+			Body:      node.Body,
+			Slots:     node.Slots,
+			Generator: node.Generator,
+		}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
+		if node.Function.TokenLiteral() == "quote" {
+			if len(node.Arguments) != 1 {
+				return positioned(newError("wrong number of arguments. got=%d, want=1", len(node.Arguments)), node.Token)
+			}
+			return quote(node.Arguments[0], env)
+		}
+		function := eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 2 && isError(args[0]) {
-			return args[0]
+		args, named, err := evalArguments(node.Arguments, env)
+		if err != nil {
+			return err
+		}
+		if bm, ok := function.(*object.BoundMethod); ok && node.Tail {
+			function = bm.Method
+			args = append([]object.Object{bm.Receiver}, args...)
 		}
-		return applyFunc(function, args)
+		if fn, ok := function.(*object.Function); ok && node.Tail {
+			return &tailCall{fn: fn, args: args, named: named, token: node.Token}
+		}
+		return positioned(applyFunc(function, args, named), node.Token)
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.ArrayLiteral:
@@ -78,25 +135,60 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
 	case *ast.IndexExpr:
-		left := Eval(node.Left, env)
+		left := eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
+		index := eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
-		return evalIndexExpr(left, index)
+		return positioned(evalIndexExpr(left, index), node.Token)
+	case *ast.SliceExpr:
+		return positioned(evalSliceExpr(node, env), node.Token)
+	case *ast.RangeExpr:
+		return positioned(evalRangeExpr(node, env), node.Token)
+	case *ast.PipeExpr:
+		return eval(node.Call(), env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.SpawnExpr:
+		return evalSpawnExpr(node, env)
+	case *ast.SelectExpression:
+		return evalSelectExpression(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.YieldExpr:
+		return evalYieldExpr(node, env)
+	case *ast.MemberExpr:
+		obj := eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return positioned(evalMemberExpr(obj, node.Property.Value), node.Property.Token)
 	}
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
-	var result object.Object
+// internalError is the error for r, recovered from a Go panic.
+func internalError(r interface{}) *object.Error {
+	return newError("internal error: %v", r)
+}
+
+// recoverInternal sets *result to an internal error if the caller is
+// panicking. It must be deferred.
+func recoverInternal(result *object.Object) {
+	if r := recover(); r != nil {
+		*result = internalError(r)
+	}
+}
 
+func evalProgram(program *ast.Program, env *object.Environment) (result object.Object) {
 	for _, stmt := range program.Statements {
-		result = Eval(stmt, env)
+		result = eval(stmt, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -109,26 +201,237 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
+func evalMemberExpr(obj object.Object, name string) object.Object {
+	if s, ok := obj.(*object.Struct); ok {
+		if field, ok := s.Field(name); ok {
+			return field
+		}
+		if m, ok := s.Def.Method(name); ok {
+			return &object.BoundMethod{Name: name, Receiver: s, Method: m}
+		}
+		return newError("struct %s has no field or method %s", s.Def.Name, name)
+	}
+
+	if enum, ok := obj.(*object.Enum); ok {
+		return enumMember(enum, name)
+	}
+	if ch, ok := obj.(*object.Channel); ok {
+		return channelMember(ch, name)
+	}
+
+	module, ok := obj.(*object.Module)
+	if !ok {
+		return newError("member access not supported: %s", obj.Type())
+	}
+
+	member, ok := module.Members[name]
+	if !ok {
+		return newError("module %s has no member %s", module.Name, name)
+	}
+	return member
+}
+
 func evalIndexExpr(left, index object.Object) object.Object {
+	if m, ok := method(left, "index"); ok {
+		return applyFunc(m, []object.Object{left, index}, nil)
+	}
+
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpr(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpr(left, index)
+	case left.Type() == object.RANGE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalRangeIndexExpr(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpr(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
 }
 
+// evalStringIndexExpr returns the rune at index as a string of its own.
+func evalStringIndexExpr(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, ok := elementIndex(index, int64(len(runes)))
+	if !ok {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+func evalRangeIndexExpr(rng, index object.Object) object.Object {
+	r := rng.(*object.Range)
+	idx, ok := elementIndex(index, r.Len())
+	if !ok {
+		return NULL
+	}
+
+	return r.At(idx)
+}
+
+// elementIndex returns the position in a sequence of length elements
+// that index refers to, counting from the end if it is negative. ok is
+// false if it is out of range.
+func elementIndex(index object.Object, length int64) (idx int64, ok bool) {
+	idx = index.(*object.Integer).Value
+	if idx < 0 {
+		idx += length
+	}
+	return idx, idx >= 0 && idx < length
+}
+
+func evalSliceExpr(node *ast.SliceExpr, env *object.Environment) object.Object {
+	left := eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var length int64
+	switch left := left.(type) {
+	case *object.String:
+		length = int64(utf8.RuneCountInString(left.Value))
+	case *object.Array:
+		length = int64(len(left.Elements))
+	case *object.Range:
+		length = left.Len()
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	start, errObj := evalSliceBound(node.Start, 0, env)
+	if errObj != nil {
+		return errObj
+	}
+	end, errObj := evalSliceBound(node.End, length, env)
+	if errObj != nil {
+		return errObj
+	}
+
+	// Negative bounds count from the end.
+	from, to := start, end
+	if from < 0 {
+		from += length
+	}
+	if to < 0 {
+		to += length
+	}
+	if from < 0 || to > length || from > to {
+		return newError("slice bounds out of range [%d:%d] with length %d", start, end, length)
+	}
+
+	switch left := left.(type) {
+	case *object.String:
+		return &object.String{Value: string([]rune(left.Value)[from:to])}
+	case *object.Range:
+		return &object.Range{Start: left.Start + from, End: left.Start + to}
+	default:
+		elements := left.(*object.Array).Elements[from:to]
+		return &object.Array{Elements: append([]object.Object(nil), elements...)}
+	}
+}
+
+// evalRangeExpr makes the range Start..End, both of which must be
+// integers.
+func evalRangeExpr(node *ast.RangeExpr, env *object.Environment) object.Object {
+	start := eval(node.Start, env)
+	if isError(start) {
+		return start
+	}
+	end := eval(node.End, env)
+	if isError(end) {
+		return end
+	}
+
+	s, ok := start.(*object.Integer)
+	e, ok2 := end.(*object.Integer)
+	if !ok || !ok2 {
+		return newError("range bounds must be INTEGER, got %s%s%s", start.Type(), node.Token.Literal, end.Type())
+	}
+	r := &object.Range{Start: s.Value, End: e.Value, Inclusive: node.Inclusive}
+
+	// The length of a range must fit in an int64. The difference of the
+	// bounds does as a uint64.
+	if e.Value > s.Value {
+		size := uint64(e.Value) - uint64(s.Value)
+		if size > math.MaxInt64 || (node.Inclusive && size == math.MaxInt64) {
+			return newError("range too long: %s", r.Inspect())
+		}
+	}
+	return r
+}
+
+// evalSliceBound evaluates one bound of a slice expression, using def when
+// the bound is omitted.
+func evalSliceBound(bound ast.Expression, def int64, env *object.Environment) (int64, object.Object) {
+	if bound == nil {
+		return def, nil
+	}
+
+	val := eval(bound, env)
+	if isError(val) {
+		return 0, val
+	}
+	integer, ok := val.(*object.Integer)
+	if !ok {
+		return 0, newError("slice index must be INTEGER, got %s", val.Type())
+	}
+
+	return integer.Value, nil
+}
+
 func evalArrayIndexExpr(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
-	if idx < 0 || idx > max {
+	idx, ok := elementIndex(index, int64(len(arrayObject.Elements)))
+	if !ok {
 		return NULL
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+func evalHashIndexExpr(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hashObject.Pairs[key.HashKey()]
+	if !ok {
+		return NULL
+	}
+
+	return pair.Value
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	for i, keyNode := range node.Keys {
+		key := eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := eval(node.Values[i], env)
+		if isError(value) {
+			return value
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return &object.Hash{Pairs: pairs}
+}
+
 func nativeBoolToBoolObj(input bool) *object.Boolean {
 	if input {
 		return TRUE
@@ -140,7 +443,7 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	var result object.Object
 
 	for _, stmt := range block.Statements {
-		result = Eval(stmt, env)
+		result = eval(stmt, env)
 
 		if result != nil {
 			rt := result.Type()
@@ -178,24 +481,49 @@ func evalBang(right object.Object) object.Object {
 }
 
 func evalMinusPrefix(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return object.NewInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalInfix(op string, left object.Object, right object.Object) object.Object {
+	if op == "==" || op == "!=" {
+		if eq, args, ok := object.EqMethod(left, right); ok {
+			result := applyFunc(eq, args, nil)
+			if isError(result) {
+				return result
+			}
+			return nativeBoolToBoolObj(isTruthy(result) == (op == "=="))
+		}
+	}
+
 	switch {
+	case op == ">>" && callable(left) && callable(right):
+		return compose(left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntInfix(op, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigInfix(op, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfix(op, left, right)
 	case op == "==":
-		return nativeBoolToBoolObj(left == right)
+		return nativeBoolToBoolObj(object.Equal(left, right))
 	case op == "!=":
-		return nativeBoolToBoolObj(left != right)
+		return nativeBoolToBoolObj(!object.Equal(left, right))
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfix(op, left, right)
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ:
+		return evalOrderingInfix(op, left, right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), op, right.Type())
 	default:
@@ -205,7 +533,7 @@ func evalInfix(op string, left object.Object, right object.Object) object.Object
 
 func evalStringInfix(op string, left, right object.Object) object.Object {
 	if op != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+		return evalOrderingInfix(op, left, right)
 	}
 
 	leftVal := left.(*object.String).Value
@@ -213,19 +541,110 @@ func evalStringInfix(op string, left, right object.Object) object.Object {
 	return &object.String{Value: leftVal + rightVal}
 }
 
+func evalOrderingInfix(op string, left, right object.Object) object.Object {
+	if op != "<" && op != ">" {
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+
+	c, err := object.Compare(left, right)
+	if err != nil {
+		return newError("%s", err)
+	}
+
+	if op == "<" {
+		return nativeBoolToBoolObj(c < 0)
+	}
+	return nativeBoolToBoolObj(c > 0)
+}
+
 func evalIntInfix(op string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
+	var result int64
+	var exact bool
+
 	switch op {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		result, exact = addInt(leftVal, rightVal)
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		result, exact = subInt(leftVal, rightVal)
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		result, exact = mulInt(leftVal, rightVal)
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return newError("integer overflow: %d / %d", leftVal, rightVal)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		if rightVal == -1 {
+			return &object.Integer{Value: 0}
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "<<":
+		n, err := shiftCount(right)
+		if err != nil {
+			return err
+		}
+		result = leftVal << n
+		exact = n < 64 && result>>n == leftVal
+	case ">>":
+		n, err := shiftCount(right)
+		if err != nil {
+			return err
+		}
+		return &object.Integer{Value: leftVal >> n}
+	case "<":
+		return nativeBoolToBoolObj(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBoolObj(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBoolObj(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBoolObj(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+
+	if !exact {
+		return evalBigInfix(op, left, right)
+	}
+	return &object.Integer{Value: result}
+}
+
+func isNumber(obj object.Object) bool {
+	return isInteger(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	f, _ := object.ToFloat(obj)
+	return f
+}
+
+// evalFloatInfix evaluates an operator on two numbers at least one of
+// which is a float. The integer operand, if any, is converted. Division
+// and modulo by zero follow IEEE 754 rather than being errors.
+func evalFloatInfix(op string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch op {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
 	case "<":
 		return nativeBoolToBoolObj(leftVal < rightVal)
 	case ">":
@@ -240,16 +659,16 @@ func evalIntInfix(op string, left object.Object, right object.Object) object.Obj
 }
 
 func evalIf(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+	condition := eval(ie.Condition, env)
 
 	if isError(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return eval(ie.Alternative, env)
 	} else {
 		return NULL
 	}
@@ -272,6 +691,15 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// positioned records the position of tok on obj if it is an error that
+// does not know where it happened yet.
+func positioned(obj object.Object, tok token.Token) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Line == 0 {
+		err.Line, err.Column = tok.Line, tok.Column
+	}
+	return obj
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -279,7 +707,23 @@ func isError(obj object.Object) bool {
 	return false
 }
 
+// bind sets the variable declared by ident, in its slot if the resolver
+// gave it one.
+func bind(ident *ast.Identifier, val object.Object, env *object.Environment) {
+	if ident.Local != nil {
+		env.SetAt(ident.Local.Slot, val)
+	} else {
+		env.Set(ident.Value, val)
+	}
+}
+
 func evalIdent(node *ast.Identifier, env *object.Environment) object.Object {
+	if node.Local != nil {
+		if val, ok := env.GetAt(node.Local.Depth, node.Local.Slot); ok {
+			return val
+		}
+	}
+
 	if val, ok := env.Get(node.Value); ok {
 		return val
 	}
@@ -288,42 +732,213 @@ func evalIdent(node *ast.Identifier, env *object.Environment) object.Object {
 		return builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	if module, ok := modules[node.Value]; ok {
+		return module
+	}
+
+	return positioned(newError("identifier not found: "+node.Value), node.Token)
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, e := range exps {
-		eval := Eval(e, env)
-		if isError(eval) {
-			return []object.Object{eval}
+		evaluated := eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
 		}
-		result = append(result, eval)
+		result = append(result, evaluated)
 	}
 	return result
 }
 
-func applyFunc(fn object.Object, args []object.Object) object.Object {
+// namedArg is an argument passed by name: f(name: value).
+type namedArg struct {
+	name  string
+	value object.Object
+}
+
+// evalArguments evaluates the arguments of a call, expanding spread
+// arrays into positional arguments.
+func evalArguments(exps []ast.Expression, env *object.Environment) ([]object.Object, []namedArg, object.Object) {
+	var args []object.Object
+	var named []namedArg
+
+	for _, e := range exps {
+		switch e := e.(type) {
+		case *ast.SpreadExpr:
+			val := eval(e.Value, env)
+			if isError(val) {
+				return nil, nil, val
+			}
+			elems, ok, err := elements(val)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok {
+				return nil, nil, positioned(newError("cannot spread %s, want ARRAY", val.Type()), e.Token)
+			}
+			args = append(args, elems...)
+		case *ast.NamedArgument:
+			val := eval(e.Value, env)
+			if isError(val) {
+				return nil, nil, val
+			}
+			named = append(named, namedArg{name: e.Name.Value, value: val})
+		default:
+			val := eval(e, env)
+			if isError(val) {
+				return nil, nil, val
+			}
+			args = append(args, val)
+		}
+	}
+	return args, named, nil
+}
+
+func applyFunc(fn object.Object, args []object.Object, named []namedArg) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFuncEnv(fn, args)
-		eval := Eval(fn.Body, extendedEnv)
-		return unwrapReturnVal(eval)
+		// Calls in tail position come back as tailCalls, which are made
+		// here rather than nesting another Eval.
+		var tail *tailCall
+		for {
+			extendedEnv, err := extendFuncEnv(fn, args, named)
+			if err != nil && tail != nil {
+				return positioned(err, tail.token)
+			}
+			if err != nil {
+				return err
+			}
+			if fn.Generator {
+				return newGenerator(fn, extendedEnv)
+			}
+			result := unwrapReturnVal(eval(fn.Body, extendedEnv))
+
+			var ok bool
+			if tail, ok = result.(*tailCall); !ok {
+				return result
+			}
+			fn, args, named = tail.fn, tail.args, tail.named
+		}
+	case *object.StructDef:
+		return newStruct(fn, args, named)
+	case *object.VariantDef:
+		return newVariant(fn, args, named)
+	case *object.BoundMethod:
+		return applyFunc(fn.Method, append([]object.Object{fn.Receiver}, args...), named)
 	case *object.Builtin:
+		if len(named) > 0 {
+			return newError("builtin functions take no named arguments, got %s", named[0].name)
+		}
 		return fn.Fn(args...)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extendFuncEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnv(fn.Env)
+// extendFuncEnv binds the parameters of fn to the arguments of a call:
+// positional ones first, then named ones, then defaults, which are
+// evaluated in the new environment so they can refer to earlier
+// parameters.
+func extendFuncEnv(fn *object.Function, args []object.Object, named []namedArg) (*object.Environment, object.Object) {
+	var env *object.Environment
+	if fn.Slots > 0 {
+		env = object.NewFrameEnv(fn.Env, fn.Slots)
+	} else {
+		env = object.NewEnclosedEnv(fn.Env)
+	}
+
+	fixed := len(fn.Params)
+	if fn.Variadic {
+		fixed--
+	}
+	min, max := arity(fn)
+	if max >= 0 && len(args) > max {
+		return nil, arityError(min, max, len(args)+len(named))
+	}
 
-	for paramIdx, param := range fn.Params {
-		env.Set(param.Value, args[paramIdx])
+	bound := make([]bool, fixed)
+	for i := 0; i < fixed && i < len(args); i++ {
+		bind(fn.Params[i], args[i], env)
+		bound[i] = true
+	}
+	if fn.Variadic {
+		var rest []object.Object
+		if len(args) > fixed {
+			rest = append(rest, args[fixed:]...)
+		}
+		bind(fn.Params[fixed], &object.Array{Elements: rest}, env)
+	}
+
+	for _, arg := range named {
+		i := paramIndex(fn.Params[:fixed], arg.name)
+		switch {
+		case i < 0:
+			return nil, newError("unknown parameter %s", arg.name)
+		case bound[i]:
+			return nil, newError("argument %s given more than once", arg.name)
+		}
+		bind(fn.Params[i], arg.value, env)
+		bound[i] = true
+	}
+
+	for i := 0; i < fixed; i++ {
+		if bound[i] {
+			continue
+		}
+		if i >= len(fn.Defaults) || fn.Defaults[i] == nil {
+			if len(named) == 0 {
+				return nil, arityError(min, max, len(args))
+			}
+			return nil, newError("missing argument for parameter %s", fn.Params[i].Value)
+		}
+		val := eval(fn.Defaults[i], env)
+		if isError(val) {
+			return nil, val
+		}
+		bind(fn.Params[i], val, env)
+	}
+
+	return env, nil
+}
+
+// arity returns the smallest and largest number of arguments fn accepts.
+// max is -1 for variadic functions.
+func arity(fn *object.Function) (min, max int) {
+	max = len(fn.Params)
+	if fn.Variadic {
+		max--
+	}
+	for i := 0; i < max; i++ {
+		if i >= len(fn.Defaults) || fn.Defaults[i] == nil {
+			min++
+		}
+	}
+	if fn.Variadic {
+		max = -1
+	}
+	return min, max
+}
+
+func arityError(min, max, got int) *object.Error {
+	switch {
+	case max < 0:
+		return newError("wrong number of arguments: want at least %d, got=%d", min, got)
+	case min == max:
+		return newError("wrong number of arguments: want=%d, got=%d", min, got)
+	default:
+		return newError("wrong number of arguments: want=%d to %d, got=%d", min, max, got)
+	}
+}
+
+func paramIndex(params []*ast.Identifier, name string) int {
+	for i, p := range params {
+		if p.Value == name {
+			return i
+		}
 	}
-	return env
+	return -1
 }
 
 func unwrapReturnVal(obj object.Object) object.Object {
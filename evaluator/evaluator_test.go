@@ -1,9 +1,15 @@
 package evaluator
 
 import (
+	"math"
+	"monkey/ast"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -40,6 +46,7 @@ func testEval(input string) object.Object {
 	p := parser.New(l)
 	program := p.ParseProgram()
 	env := object.NewEnvironment()
+	Resolve(program)
 
 	return Eval(program, env)
 }
@@ -251,6 +258,776 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "ERROR: 1:3: division by zero"},
+		{"let x = 0;\n  5 % x", "ERROR: 2:5: modulo by zero"},
+		{"9223372036854775808 / 0", "ERROR: 1:21: division by zero"},
+		{"(-9223372036854775807 - 1) / -1", "ERROR: 1:28: integer overflow: -9223372036854775808 / -1"},
+		{"1 << -1", "ERROR: 1:3: negative shift count: -1"},
+		{"1 << 100000", "ERROR: 1:3: shift count too large: 100000"},
+		{"let f = fn(x) { 10 / x }; f(0)", "ERROR: 1:20: division by zero"},
+		{"len(1 / 0)", "ERROR: 1:7: division by zero"},
+		{"len(1, 2)", "ERROR: 1:4: wrong number of arguments. got=2, want=1"},
+		{"y", "ERROR: 1:1: identifier not found: y"},
+		{"7 % 3", "1"},
+		{"-7 % 3", "-1"},
+		{"(-9223372036854775807 - 1) % -1", "0"},
+		{"7.5 % 2", "1.5"},
+		{"1.0 / 0", "+Inf"},
+		{"1 << 3 + 1", "16"},
+		{"-16 >> 2", "-4"},
+		{"1 >> 64", "0"},
+		{"1 << 64", "18446744073709551616"},
+		{"(1 << 64) >> 63", "2"},
+		{"(1 << 64) % 7", "2"},
+	}
+
+	for _, tt := range tests {
+		if got := testEval(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("%q: want %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestInternalErrorsDoNotPanic(t *testing.T) {
+	program := &ast.Program{Statements: []ast.Statement{
+		&ast.ExpressionStatement{Expression: &ast.InfixExpression{Operator: "+"}},
+	}}
+
+	errObj, ok := Eval(program, object.NewEnvironment()).(*object.Error)
+	if !ok {
+		t.Fatalf("expected an error object")
+	}
+	if !strings.HasPrefix(errObj.Message, "internal error: ") {
+		t.Errorf("wrong message. got=%q", errObj.Message)
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"match (0) { 0 => 1, _ => 2 }", 1},
+		{"match (5) { 0 => 1, _ => 2 }", 2},
+		{"match (-1) { -1 => 1, _ => 2 }", 1},
+		{`match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{"match (1.0) { 1 => true, _ => false }", true},
+		{"match (7) { n => n * 2 }", 14},
+		{"match (7) { x if x > 10 => 1, x if x > 5 => 2, _ => 3 }", 2},
+		{"let x = 10; match ([1, 2]) { [x, 3] => 0, _ => x }", 10},
+		{"let x = 10; match (5) { x if x > 100 => 0, _ => 1 }; x", 10},
+		{"let x = 10; match (5) { x if x > 1 => 0, _ => 1 }; x", 5},
+		{"let f = fn() { let x = 10; match ([1, 2]) { [x, 3] => 0, _ => x } }; f()", 10},
+		{"let f = fn() { let x = 10; match (5) { x if x > 100 => 0, _ => 1 }; x }; f()", 10},
+		{"let f = fn() { match (5) { x if x > 100 => 0, _ => 1 }; x }; f()", "identifier not found: x"},
+		{"let f = fn() { let x = 10; match (5) { x if x > 1 => 0, _ => 1 }; x }; f()", 5},
+		{"match ([]) { [] => 0, _ => 1 }", 0},
+		{"match ([1, 2, 3]) { [a, b] => 0, [first, ...rest] => first + len(rest) }", 3},
+		{"match ([1]) { [a, ...rest] => len(rest) }", 0},
+		{"match ([1, [2, 3]]) { [1, [_, x]] => x }", 3},
+		{"match ([1, 2]) { [_, _, ..._] => 1, [x] => 2, _ => 3 }", 1},
+		{`match ({"kind": "circle", "r": 2}) { {"kind": "square"} => 0, {"kind": k, "r": r} => len(k) + r }`, 8},
+		{`match ({1: true}) { {1: false} => 1, {1: b} => b }`, true},
+		{"match (3) { 1 => 1, 2 => { let y = 2; y } 3 => { let z = 3; z * 10 } }", 30},
+		{"match (true) { x => 1 } + 1", 2},
+		{"let f = fn(v) { match (v) { [h, ...t] => h + f(t), [] => 0 } }; f([1, 2, 3])", 6},
+		{"let f = fn(v) { let g = fn() { x }; match (v) { x => g() } }; f(4)", 4},
+		{"let v = 5; match (v + 1) { x if x == v => 1, x => x }; x", 6},
+		{"match (range(0, 3)) { [a] => 1, [a, b, c] => a + b + c }", 3},
+		{"match (range(0, 10000000000)) { [a] => 1, [a, b] => 2, [a, b, c, ..._] => a + b + c }", 3},
+		{"type R { calls }; impl R { fn iter(self) { self.calls = self.calls + 1; [1, 2] } }; let r = R(0); match (r) { [a] => 0, [a, b, c] => 0, [a, b] => r.calls }", 1},
+		{"match (3) { 1 => 1, 2 => 2 }", "no match arm matches 3"},
+		{"match ([1]) { [] => 1 }", "no match arm matches [1]"},
+		{"match (1) { x if 1 / 0 => x }", "division by zero"},
+		{"match (1 / 0) { _ => 1 }", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestDestructuringLet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a * 10 + b", 12},
+		{"let [a, ...rest] = [1, 2, 3]; len(rest) * 10 + a", 21},
+		{"let [a, ...rest] = [1]; len(rest)", 0},
+		{"let [_, [b, c]] = [1, [2, 3]]; b + c", 5},
+		{"let [a, b = 5] = [1]; a + b", 6},
+		{"let [a, b = a * 2] = [3]; b", 6},
+		{"let [a = 1] = []; a", 1},
+		{`let {name, age} = {"name": "ann", "age": 30}; len(name) + age`, 33},
+		{`let {name, age = 18} = {"name": "bo"}; age`, 18},
+		{`let {pos: {x, y}} = {"pos": {"x": 1, "y": 2}}; x + y`, 3},
+		{`let {pos: [x, y]} = {"pos": [3, 4]}; x * y`, 12},
+		{`let {1: one, true: yes} = {1: "a", true: "b"}; one + yes`, "ab"},
+		{"let divmod = fn(a, b) { [a / b, a % b] }; let [q, r] = divmod(7, 2); q * 10 + r", 31},
+		{"let f = fn(p) { let [x, y] = p; let g = fn() { x + y }; g() }; f([2, 3])", 5},
+		{"let [a, 1] = [5, 1]; a", 5},
+		{"let [a, b, ...rest] = range(0, 5); a + b + len(rest)", 4},
+		{"let [a, ...rest] = 0..3; len(rest)", 2},
+		{"let [a, b] = [1, 2, 3];", "cannot destructure [1, 2, 3]: expected 2 elements, got 3"},
+		{"let [a, b] = 0..10000000000;", "cannot destructure 0..10000000000: expected 2 elements, got 10000000000"},
+		{"let [a, b] = range(0, 10000000000);", "cannot destructure <iterator>: expected 2 elements, got more than 2"},
+		{"let [a, b, ...c] = [1];", "cannot destructure [1]: expected at least 2 elements, got 1"},
+		{"let [a, b = 1] = [];", "cannot destructure []: expected 1 to 2 elements, got 0"},
+		{"let [a, b] = 5;", "cannot destructure 5: expected an array, got INTEGER"},
+		{"let [a, [b]] = [1, 2];", "cannot destructure [1, 2]: expected an array, got INTEGER"},
+		{"let {a} = [1];", "cannot destructure [1]: expected a hash, got ARRAY"},
+		{`let {name} = {"age": 1};`, "cannot destructure {age: 1}: missing key: name"},
+		{"let [a, 1] = [5, 2];", "cannot destructure [5, 2]: expected 1, got 2"},
+		{"let [a = 1 / 0] = [];", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn(a, b = 2) { a * 10 + b }; f(1)", 12},
+		{"let f = fn(a, b = 2) { a * 10 + b }; f(1, 3)", 13},
+		{"let f = fn(a, b = a + 1) { b }; f(4)", 5},
+		{"let x = 7; let f = fn(a = x) { a }; f()", 7},
+		{"let f = fn(a, ...rest) { len(rest) }; f(1, 2, 3)", 2},
+		{"let f = fn(a, ...rest) { rest }; len(f(1))", 0},
+		{"let f = fn(a = 1, ...rest) { a + len(rest) }; f()", 1},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(...[1, 2, 3])", 123},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(1, ...[2], 3)", 123},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(1, ...[], 2, 3)", 123},
+		{"let f = fn(...xs) { len(xs) }; f(...[1, 2], ...[3])", 3},
+		{"let f = fn(a, b = 2, c = 3) { a * 100 + b * 10 + c }; f(1, c: 5)", 125},
+		{"let f = fn(a, b) { a - b }; f(b: 1, a: 5)", 4},
+		{`let f = fn(s) { len(s) }; f(..."abc")`, "cannot spread STRING, want ARRAY"},
+		{"let f = fn(a, b) { a }; f(1)", "wrong number of arguments: want=2, got=1"},
+		{"let f = fn(a, b) { a }; f(1, 2, 3)", "wrong number of arguments: want=2, got=3"},
+		{"let f = fn(a, b = 1) { a }; f()", "wrong number of arguments: want=1 to 2, got=0"},
+		{"let f = fn(a, b, ...c) { a }; f(1)", "wrong number of arguments: want at least 2, got=1"},
+		{"fn() { 1 }(1)", "wrong number of arguments: want=0, got=1"},
+		{"let f = fn(a, b) { a }; f(1, c: 2)", "unknown parameter c"},
+		{"let f = fn(a, b) { a }; f(1, a: 2)", "argument a given more than once"},
+		{"let f = fn(a, b) { a }; f(a: 2)", "missing argument for parameter b"},
+		{"let f = fn(a, ...r) { a }; f(1, r: [])", "unknown parameter r"},
+		{"let f = fn(a = 1 / 0) { a }; f()", "division by zero"},
+		{"len(s: 1)", "builtin functions take no named arguments, got s"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	// Without tail calls each Monkey call nests several Go frames, so
+	// these would overflow a small stack.
+	defer debug.SetMaxStack(debug.SetMaxStack(4 << 20))
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } }; sum(100000, 0)", 5000050000},
+		{"let count = fn(n) { if (n == 0) { return 0; } return count(n - 1); }; count(100000)", 0},
+		{"let count = fn(n) { match (n) { 0 => true, _ => count(n - 1) } }; count(100000)", true},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(100001)", false},
+		{"let loop = fn(n, acc = 0) { if (n == 0) { acc } else { loop(n - 1, acc: acc + 2) } }; loop(100000)", 200000},
+		{"let f = fn(n) { if (n == 0) { len([1, 2]) } else { f(n - 1) } }; f(100000)", 2},
+		{"let f = fn(n) { if (n == 0) { g() } else { f(n - 1) } }; let g = fn(a) { a }; f(3)", "wrong number of arguments: want=1, got=0"},
+		{"let f = fn(n) { if (n == 0) { 1 / n } else { f(n - 1) } }; f(3)", "division by zero"},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(20)", 2432902008176640000},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+
+	// The parser marks tail calls, so they need no Resolve.
+	input := tests[0].input
+	program := parser.New(lexer.New(input)).ParseProgram()
+	testObject(t, input, Eval(program, object.NewEnvironment()), tests[0].expected)
+}
+
+func TestTailCallErrorPosition(t *testing.T) {
+	input := "let f = fn(n) { if (n == 0) { g() } else { f(n - 1) } };\nlet g = fn(a) { a };\nf(3)"
+	err, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("expected an error, got %T", err)
+	}
+	if err.Line != 1 || err.Column != 32 {
+		t.Errorf("wrong position. want=1:32, got=%d:%d", err.Line, err.Column)
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"struct Point { x, y }; let p = Point(1, 2); p.x * 10 + p.y", 12},
+		{"struct Point { x, y }; Point(y: 2, x: 1).y", 2},
+		{"struct Point { x, y }; Point(1, y: 2).y", 2},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x = 5; p.x", 5},
+		{"struct Point { x, y }; let p = Point(1, 2); let q = p; q.y = 7; p.y", 7},
+		{"struct Point { x, y }; Point(1, 2) == Point(1, 2)", true},
+		{"struct Point { x, y }; Point(1, 2) != Point(2, 1)", true},
+		{"struct A { v }; struct B { v }; A(1) == B(1)", false},
+		{"struct Box { v }; Box([1, 2]) == Box([1, 2])", true},
+		{"struct Point { x, y }; let p = Point(1, 2); match ([p]) { [q] => q.x }", 1},
+		{"let f = fn() { struct P { a }; P(3) }; f().a", 3},
+		{"struct Point { x, y }; Point(1)", "wrong number of arguments: want=2, got=1"},
+		{"struct Point { x, y }; Point(1, 2, 3)", "wrong number of arguments: want=2, got=3"},
+		{"struct Point { x, y }; Point(1, z: 2)", "struct Point has no field z"},
+		{"struct Point { x, y }; Point(1, x: 2)", "argument x given more than once"},
+		{"struct Point { x, y }; Point(y: 2)", "missing field x of struct Point"},
+		{"struct Point { x, y }; Point(1, 2).z", "struct Point has no field or method z"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.z = 1;", "struct Point has no field z"},
+		{"let a = [1]; a.x = 1;", "cannot assign to member x of ARRAY"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x = 1 / 0;", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"type P { x, y }; impl P { fn sum(self) { self.x + self.y } }; P(1, 2).sum()", 3},
+		{"type P { x }; impl P { fn add(self, n) { self.x + n } }; let f = P(1).add; f(2)", 3},
+		{"type P { x }; impl P { fn bump(self) { self.x = self.x + 1; self } }; let p = P(1); p.bump().bump(); p.x", 3},
+		{"type P { x }; impl P { fn a(self) { self.b() }; fn b(self) { self.x * 2 } }; P(4).a()", 8},
+		{"type P { x }; impl P { fn scale(self, by = 2) { self.x * by } }; P(3).scale(by: 5)", 15},
+		{"type P { x }; impl P { fn get(self) { self.x } }; impl P { fn twice(self) { self.get() * 2 } }; P(2).twice()", 4},
+		{"type C { n }; impl C { fn down(self) { if (self.n == 0) { 0 } else { C(self.n - 1).down() } } }; C(100000).down()", 0},
+		{"type P { x }; impl P { fn eq(self, o) { self.x % 10 == o.x % 10 } }; P(1) == P(11)", true},
+		{"type P { x }; impl P { fn eq(self, o) { self.x % 10 == o.x % 10 } }; P(1) != P(2)", true},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; P(5) == 5", true},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; 5 == P(5)", true},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; 5 != P(5)", false},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; [P(5)] == [5]", true},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; contains([P(1), P(2)], 2)", true},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; index_of([P(1), P(2)], 2)", 1},
+		{"type P { x }; impl P { fn eq(self, o) { self.x == o } }; match (P(3)) { 1 => 1, 3 => 3, _ => 0 }", 3},
+		{"type P { x }; impl P { fn eq(self, o) { false } }; let p = P(1); contains([p], p)", false},
+		{"type V { xs }; impl V { fn index(self, i) { self.xs[i] * 10 } }; V([1, 2])[1]", 20},
+		{"type R { n }; impl R { fn iter(self) { [self.n, self.n + 1] } }; let [a, b] = R(5); a * b", 30},
+		{"type R { n }; impl R { fn iter(self) { [self.n, self.n] } }; let f = fn(a, b) { a + b }; f(...R(4))", 8},
+		{"type R { n }; impl R { fn iter(self) { self.n } }; let [a] = R(1);", "iter must return ARRAY or ITERATOR, got INTEGER"},
+		{"type P { x }; P(1).nope()", "struct P has no field or method nope"},
+		{"type P { x }; impl P { fn x(self) { 1 } }", "struct P already has a field x"},
+		{"let n = 1; impl n { fn f(self) { 1 } }", "cannot impl methods on INTEGER"},
+		{"type P { x }; impl P { fn f(self, a) { a } }; P(1).f()", "wrong number of arguments: want=2, got=1"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMethodInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`type P { x }; impl P { fn to_string(self) { "<" + self.x + ">" } }; [P("a"), P("b").to_string]`, "[<a>, method P.to_string]"},
+		{"type P { x }; impl P { fn to_string(self) { self } }; P(1)", "P{x: 1}"},
+		{"type P { x }; impl P { fn to_string(self) { self.x } }; P(1)", "P{x: 1}"},
+	}
+
+	for _, tt := range tests {
+		if got := testEval(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("wrong Inspect(). want=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	result := "enum Result { Ok(value), Err(message) }; "
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{result + "match (Result.Ok(5)) { Result.Ok(v) => v, Result.Err(m) => m }", 5},
+		{result + `match (Result.Err("bad")) { Result.Ok(v) => v, Result.Err(m) => m }`, "bad"},
+		{result + "match (Result.Ok(5)) { Result.Ok(0) => 0, Result.Ok(_) => 1, Result.Err(_) => 2 }", 1},
+		{result + "match (Result.Ok(5)) { Result.Err => 0, Result.Ok => 1 }", 1},
+		{result + "match (Result.Ok(5)) { Result.Err(_) => 0, _ => 1 }", 1},
+		{result + "let f = fn(n) { if (n < 0) { Result.Err(n) } else { Result.Ok(n) } }; match (f(-1)) { Result.Ok(v) => v, Result.Err(m) => m * 10 }", -10},
+		{"enum Option { Some(v), None }; match ([Option.None, Option.Some(2)]) { [Option.None, Option.Some(x)] => x }", 2},
+		{"enum Option { Some(v), None }; Option.None == Option.None", true},
+		{"enum Option { Some(v), None }; Option.Some([1]) == Option.Some([1])", true},
+		{"enum Option { Some(v), None }; Option.Some(1) != Option.Some(2)", true},
+		{"enum A { X }; enum B { X }; A.X == B.X", false},
+		{"enum Option { Some(v), None }; let some = Option.Some; some(3) == Option.Some(3)", true},
+		{result + "match (Result.Ok(5)) { Result.Ok(v) => v }", "match on Result is not exhaustive: missing Err"},
+		{result + "match (Result.Ok(5)) { Result.Ok(1) => 1, Result.Err(_) => 0 }", "match on Result is not exhaustive: missing Ok"},
+		{result + "match (Result.Ok(5)) { Result.Ok(v) if v > 0 => v, Result.Err(_) => 0 }", "match on Result is not exhaustive: missing Ok"},
+		{"enum C { R, G, B }; match (C.R) { C.R => 1 }", "match on C is not exhaustive: missing G, B"},
+		{result + "Result.Ok()", "wrong number of arguments: want=1, got=0"},
+		{result + "Result.Ok(value: 1)", "variant constructors take no named arguments, got value"},
+		{result + "Result.Maybe", "enum Result has no variant Maybe"},
+		{result + "match (1) { Result.Nope(v) => v, _ => 0 }", "enum Result has no variant Nope"},
+		{result + "match (1) { Result.Ok(a, b) => a, _ => 0 }", "wrong number of fields in pattern Result.Ok(a, b): want=1, got=2"},
+		{"let R = 1; match (1) { R.Ok(v) => v, _ => 0 }", "R is not an enum"},
+		{result + "match (1) { Result.Ok(v) => v }", "no match arm matches 1"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEnumInspect(t *testing.T) {
+	input := `enum Result { Ok(value), Err(message) }; enum Option { Some(v), None }; [Result.Ok(5), Result.Err("no"), Option.None, Option.Some, Result]`
+	got := testEval(input).Inspect()
+	expected := "[Result.Ok(5), Result.Err(no), Option.None, Option.Some, enum Result { Ok(value), Err(message) }]"
+	if got != expected {
+		t.Errorf("wrong Inspect(). want=%q, got=%q", expected, got)
+	}
+}
+
+// TestConcurrentPrograms evaluates many programs at once on a shared,
+// frozen base environment. Run it with -race.
+func TestConcurrentPrograms(t *testing.T) {
+	prelude := `
+	let square = fn(x) { x * x };
+	type P { x }
+	impl P { fn scaled(self, by) { P(self.x * by) } }
+	let origin = P(0);
+	`
+	base := object.NewEnvironment()
+	program := parser.New(lexer.New(prelude)).ParseProgram()
+	Resolve(program)
+	if result := Eval(program, base); isError(result) {
+		t.Fatalf("prelude failed: %s", result.Inspect())
+	}
+	base.Freeze()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			n := strconv.Itoa(i)
+			input := `
+			let p = P(` + n + `).scaled(2);
+			let square = fn(x) { 0 };
+			let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } };
+			p.x = p.x + origin.x;
+			[p.x, square(3), sum(100, 0), await(spawn fn() { p.x + 1 })]`
+			program := parser.New(lexer.New(input)).ParseProgram()
+			Resolve(program)
+
+			got := Eval(program, object.NewEnclosedEnv(base)).Inspect()
+			expected := "[" + strconv.Itoa(2*i) + ", 0, 5050, " + strconv.Itoa(2*i+1) + "]"
+			if got != expected {
+				t.Errorf("program %d: want=%s, got=%s", i, expected, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := base.Get("p"); ok {
+		t.Errorf("a program defined p in the base environment")
+	}
+}
+
+func TestFrozenBaseIsolation(t *testing.T) {
+	prelude := `
+	type P { x }
+	impl P { fn get(self) { self.x } }
+	let origin = P(0);
+	let boxed = fn() { let b = P(1); fn() { b } }();
+	`
+	base := object.NewEnvironment()
+	program := parser.New(lexer.New(prelude)).ParseProgram()
+	Resolve(program)
+	if result := Eval(program, base); isError(result) {
+		t.Fatalf("prelude failed: %s", result.Inspect())
+	}
+	base.Freeze()
+
+	eval := func(input string) object.Object {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		Resolve(program)
+		return Eval(program, object.NewEnclosedEnv(base))
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"origin.x = 99", "cannot assign to field x of frozen P"},
+		{`impl P { fn get(self) { "hijacked" } }`, "cannot impl methods on frozen struct P"},
+		{"boxed().x = 99", "cannot assign to field x of frozen P"},
+		{"let p = P(1); p.x = 2; p.get()", 2},
+		{"origin.get() + boxed().get()", 1},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, eval(tt.input), tt.expected)
+	}
+}
+
+// TestTasksShareState has tasks read and write globals and struct fields
+// while the main task does too. Run it with -race.
+func TestTasksShareState(t *testing.T) {
+	input := `
+	type Counter { n }
+	let c = Counter(0);
+	let total = 0;
+	let done = channel();
+	let work = fn(i) { c.n = i; let seen = c.n; done.send(total + seen) };
+	let ts = [spawn work(1), spawn work(2), spawn work(3), spawn work(4)];
+	let total = 10;
+	let wait = fn(k, acc) { if (k == 0) { acc } else { wait(k - 1, acc + done.recv()) } };
+	let got = wait(4, 0);
+	if (c.n > 0) { got > 3 } else { false }
+	`
+	testBoolObj(t, testEval(input), true)
+}
+
+func TestTasks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let t = spawn fn() { 1 + 2 }; await(t)", 3},
+		{"let add = fn(a, b) { a + b }; await(spawn add(2, b: 5))", 7},
+		{"let ts = [spawn fn() { 1 }, spawn fn() { 2 }, spawn fn() { 3 }]; await(ts[0]) + await(ts[1]) + await(ts[2])", 6},
+		{"let t = spawn fn() { 1 }; await(t) + await(t)", 2},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; await(spawn f(100000))", 0},
+		{"type P { x }; impl P { fn get(self) { self.x } }; await(spawn P(4).get())", 4},
+		{"await(spawn fn() { 1 / 0 })", "division by zero"},
+		{"await(spawn len([1, 2]))", 2},
+		{"spawn 1", "cannot spawn INTEGER"},
+		{"spawn nope()", "identifier not found: nope"},
+		{"await(1)", "argument 1 to `await` must be TASK, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTaskPanic(t *testing.T) {
+	input := "let t = spawn fn() { boom() }; await(t)"
+	testObject(t, input, testEvalPanicking(input), "internal error: boom")
+}
+
+func TestGeneratorPanic(t *testing.T) {
+	input := "let g = fn() { yield 1; boom() }; let sum = 0; for (x in g()) { let sum = sum + x }"
+	testObject(t, input, testEvalPanicking(input), "internal error: boom")
+}
+
+func TestPanicOutsideProgram(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("boom", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		panic("boom")
+	}})
+
+	stmt := parser.New(lexer.New("1 + boom()")).ParseProgram().Statements[0]
+	testObject(t, "1 + boom()", Eval(stmt, env), "internal error: boom")
+
+	input := "struct P { x }; impl P { fn to_string(self) { boom() } }; P(1)"
+	got := Inspect(testEvalPanicking(input))
+	if expected := "ERROR: internal error: boom"; got != expected {
+		t.Errorf("wrong Inspect(). want=%q, got=%q", expected, got)
+	}
+}
+
+// testEvalPanicking is testEval with a builtin boom that panics, as a bug
+// in the interpreter would.
+func testEvalPanicking(input string) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	env := object.NewEnvironment()
+	env.Set("boom", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		panic("boom")
+	}})
+	Resolve(program)
+
+	return Eval(program, env)
+}
+
+func TestChannels(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let ch = channel(1); ch.send(5); ch.recv()", 5},
+		{"let ch = channel(); spawn fn() { ch.send(7) }; ch.recv()", 7},
+		{`
+		let ch = channel();
+		let worker = fn(n) { ch.send(n * n) };
+		spawn worker(2); spawn worker(3); spawn worker(4);
+		ch.recv() + ch.recv() + ch.recv()`, 29},
+		{`
+		let ch = channel(3);
+		let producer = spawn fn() {
+			let loop = fn(i) { if (i > 5) { ch.close() } else { ch.send(i); loop(i + 1) } };
+			loop(1)
+		};
+		let sum = fn(acc) { let v = ch.recv(); if (v) { sum(acc + v) } else { acc } };
+		sum(0)`, 15},
+		{"let ch = channel(1); ch.close(); ch.recv()", nil},
+		{"let ch = channel(1); ch.close(); ch.send(1)", "send on closed channel"},
+		{"let ch = channel(1); ch.close(); ch.close()", "close of closed channel"},
+		{"let ch = channel(1); ch.size", "channel has no method size"},
+		{"channel(-1)", "channel size must not be negative, got -1"},
+		{`channel("a")`, "argument 1 to `channel` must be INTEGER, got STRING"},
+		{"channel(1, 2)", "wrong number of arguments. got=2, want=0 or 1"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = channel(1); let b = channel(1); b.send(2); select { v = a.recv() => v, v = b.recv() => v * 10 }", 20},
+		{"let a = channel(); select { v = a.recv() => v, _ => -1 }", -1},
+		{"let a = channel(1); select { a.send(4) => a.recv() }", 4},
+		{"let a = channel(); spawn fn() { a.send(9) }; select { x = a.recv() => x }", 9},
+		{"let a = channel(1); a.close(); select { x = a.recv() => x }", nil},
+		{"let a = channel(1); a.close(); select { a.send(1) => 1 }", "send on closed channel"},
+		{"select { v = (1).recv() => v }", "cannot select on INTEGER"},
+		{"let a = channel(1); select { a.send(1 / 0) => 1 }", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let g = fn() { yield 1; yield 2; yield 3 }; collect(g()) == [1, 2, 3]", true},
+		{`
+		let fib = fn() {
+			let a = 0; let b = 1;
+			for (i in range(10)) { yield a; let t = a; let a = b; let b = t + b }
+		};
+		collect(fib()) == [0, 1, 1, 2, 3, 5, 8, 13, 21, 34]`, true},
+		{`
+		let forever = fn() { for (i in range(1, 1000000000)) { yield i } };
+		collect(take(forever(), 3)) == [1, 2, 3]`, true},
+		{"let g = fn() { yield 1; return 5; yield 2 }; collect(g()) == [1]", true},
+		{"let g = fn() { yield 1; 1 / 0 }; collect(g())", "division by zero"},
+		{"let g = fn(a, b) { yield a; yield b }; let [x, y] = g(4, 5); x * y", 20},
+		{"let g = fn() { yield 1; yield 2 }; let f = fn(a, b) { a * 10 + b }; f(...g())", 12},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestForStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let s = 0; for (x in [1, 2, 3]) { let s = s + x }; s", 6},
+		{`let s = ""; for (c in "abc") { let s = c + s }; s`, "cba"},
+		{"let s = 0; for ([a, b] in [[1, 2], [3, 4]]) { let s = s + a * b }; s", 14},
+		{"let s = 0; for ({x} in [{\"x\": 1}, {\"x\": 2}]) { let s = s + x }; s", 3},
+		{"let f = fn(xs) { for (x in xs) { if (x > 2) { return x } }; 0 }; f([1, 2, 3, 4])", 3},
+		{"let f = fn(xs) { for (x in xs) { if (x > 9) { return x } }; 0 }; f([1, 2, 3, 4])", 0},
+		{"let s = 0; for (i in range(5)) { let s = s + i }; s", 10},
+		{"type Bag { items }; impl Bag { fn iter(self) { self.items } }; let s = 0; for (x in Bag([2, 3])) { let s = s + x }; s", 5},
+		{"type Two { }; impl Two { fn iter(self) { range(2) } }; let s = 0; for (x in Two()) { let s = s + x + 1 }; s", 3},
+		{"for (x in 5) { x }", "cannot iterate over INTEGER"},
+		{"for ([a, b] in [1]) { a }", "cannot destructure 1: expected an array, got INTEGER"},
+		{"for (x in [1, 0]) { 1 / x }", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestIteratorBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"collect(range(4)) == [0, 1, 2, 3]", true},
+		{"collect(range(2, 5)) == [2, 3, 4]", true},
+		{"collect(range(10, 0, -3)) == [10, 7, 4, 1]", true},
+		{"collect(range(3, 3)) == []", true},
+		{"range(1, 2, 0)", "range step must not be zero"},
+		{"range()", "wrong number of arguments. got=0, want=1 to 3"},
+		{`range("a")`, "argument 1 to `range` must be INTEGER, got STRING"},
+		{"collect(take(range(100), 3)) == [0, 1, 2]", true},
+		{"collect(take([1, 2], 5)) == [1, 2]", true},
+		{"take(range(3), -1)", "argument 2 to `take` must not be negative, got -1"},
+		{"collect(skip(range(5), 3)) == [3, 4]", true},
+		{"collect(skip([1], 3)) == []", true},
+		{"collect(map_iter(range(3), fn(x) { x * x })) == [0, 1, 4]", true},
+		{"collect(filter_iter(range(6), fn(x) { x % 2 == 0 })) == [0, 2, 4]", true},
+		{"collect(map_iter([1, 0], fn(x) { 1 / x }))", "division by zero"},
+		{`collect(zip([1, 2, 3], "ab")) == [[1, "a"], [2, "b"]]`, true},
+		{`collect(enumerate(["a", "b"])) == [[0, "a"], [1, "b"]]`, true},
+		{"collect([1, 2]) == [1, 2]", true},
+		{`take({}, 1)`, "argument 1 to `take` must be iterable, got HASH"},
+		{`zip([1], 2)`, "argument 2 to `zip` must be iterable, got INTEGER"},
+		{"let it = range(3); collect(it); collect(it) == []", true},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"len(1..10)", 9},
+		{"len(1..=10)", 10},
+		{"len(5..1)", 0},
+		{"len(3..=3)", 1},
+		{"(1..10)[0]", 1},
+		{"(1..10)[-1]", 9},
+		{"(1..=10)[-1]", 10},
+		{"(1..10)[9]", nil},
+		{"let n = 4; (0..n * 2)[7]", 7},
+		{"len((0..100)[10:20])", 10},
+		{"(0..100)[10:20][0]", 10},
+		{"(0..100)[:-1] == (0..99)", true},
+		{"1..3 == 1..=2", true},
+		{"collect(1..=4) == [1, 2, 3, 4]", true},
+		{"collect(5..1) == []", true},
+		{"let s = 0; for (i in 1..=100) { let s = s + i }; s", 5050},
+		{"let [a, b, ...r] = 1..5; a + b + len(r)", 5},
+		{"let f = fn(a, b) { a * b }; f(...3..5)", 12},
+		{"collect(map_iter(0..3, fn(x) { x * 10 })) == [0, 10, 20]", true},
+		{`1.."a"`, "range bounds must be INTEGER, got INTEGER..STRING"},
+		{`1.5..=2`, "range bounds must be INTEGER, got FLOAT..=INTEGER"},
+		{"len(0..9223372036854775807)", 9223372036854775807},
+		{"len(-1..=9223372036854775805)", 9223372036854775807},
+		{"len(0..=9223372036854775807)", "range too long: 0..=9223372036854775807"},
+		{"len(-9223372036854775807..9223372036854775807)", "range too long: -9223372036854775807..9223372036854775807"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestPipes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc" |> len`, 3},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(3)", 7},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(3, _)", -7},
+		{"let double = fn(x) { x * 2 }; [1, 2, 3] |> map(_, double) |> sum", 12},
+		{"1..=10 |> filter(_, fn(x) { x % 2 == 0 }) |> len", 5},
+		{"let f = fn(a, b: 1) { a * b }; 3 |> f(b: 4)", 12},
+		{"type P { x }; impl P { fn add(self, n) { self.x + n } }; 2 |> P(1).add", 3},
+		{"let inc = fn(x) { x + 1 }; 1 |> inc |> inc == 3", true},
+		{"1 |> 2", "not a function: INTEGER"},
+		{"1 |> nope(_)", "identifier not found: nope"},
+		{"1 / 0 |> len", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestComposition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let double = fn(x) { x * 2 }; let inc = fn(x) { x + 1 }; (double >> inc)(5)", 11},
+		{"let double = fn(x) { x * 2 }; let inc = fn(x) { x + 1 }; (inc >> double)(5)", 12},
+		{"let add = fn(a, b) { a + b }; let neg = fn(x) { -x }; (add >> neg)(2, 3)", -5},
+		{`(len >> fn(n) { n * 10 })("abcd")`, 40},
+		{"let inc = fn(x) { x + 1 }; 5 |> inc >> inc >> inc", 8},
+		{"let f = fn(x) { x / 0 } >> fn(x) { x }; f(1)", "division by zero"},
+		{"8 >> 1", 4},
+		{"fn(x) { x } >> 1", "type mismatch: FUNCTION >> INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"map([1, 2, 3], fn(x) { x * x }) == [1, 4, 9]", true},
+		{"map(0..3, fn(x) { x + 1 }) == [1, 2, 3]", true},
+		{"filter([1, 2, 3, 4], fn(x) { x > 2 }) == [3, 4]", true},
+		{`filter("abca", fn(c) { c == "a" }) == ["a", "a"]`, true},
+		{"reduce([1, 2, 3], 10, fn(acc, x) { acc + x })", 16},
+		{"reduce([], 7, fn(acc, x) { acc + x })", 7},
+		{"sum([1, 2, 3])", 6},
+		{"sum([])", 0},
+		{"sum([1, 0.5])", 1.5},
+		{"sum(range(5))", 10},
+		{"sum(filter(map(1..4, fn(x) { x * 2 }), fn(x) { x % 4 == 0 }))", 4},
+		{`sum([1, "a"])`, "argument 1 to `sum` must contain only numbers, got STRING"},
+		{"map([1, 0], fn(x) { 1 / x })", "division by zero"},
+		{"map(1, fn(x) { x })", "argument 1 to `map` must be iterable, got INTEGER"},
+		{"reduce([1], fn(a, x) { a })", "wrong number of arguments. got=2, want=3"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStructInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`struct Point { x, y }; [Point(1, "a"), Point]`, "[Point{x: 1, y: a}, struct Point { x, y }]"},
+		{`struct N { next }; let n = N(0); n.next = n; n`, "N{next: N{...}}"},
+		{`struct N { next }; let n = N(0); n.next = [n, {"a": n}]; n`, `N{next: [N{...}, {a: N{...}}]}`},
+		{`struct N { next }; let m = N(0); [N(m), N(m)]`, "[N{next: N{next: 0}}, N{next: N{next: 0}}]"},
+	}
+
+	for _, tt := range tests {
+		got := testEval(tt.input).Inspect()
+		if got != tt.expected {
+			t.Errorf("wrong Inspect() for %q. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
 func TestLetStatement(t *testing.T) {
 	tests := []struct {
 		input string
@@ -301,6 +1078,7 @@ func TestFuncApplication(t *testing.T) {
 		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
 		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
 		{"fn(x) { x; }(5);", 5},
+		{"let add = fn(x: int, y: int) -> int { x + y; }; let z: int = add(2, 3); z;", 5},
 	}
 
 	for _, tt := range tests {
@@ -342,6 +1120,7 @@ func TestBuiltin(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("héllo, 世界")`, 9},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 	}
@@ -365,6 +1144,216 @@ func TestBuiltin(t *testing.T) {
 	}
 }
 
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`join(split("a,b,c", ","), "-")`, "a-b-c"},
+		{`len(split("a b", ""))`, 3},
+		{`trim("  hi  ")`, "hi"},
+		{`upper("ñandú")`, "ÑANDÚ"},
+		{`lower("ÀB")`, "àb"},
+		{`replace("aaa", "a", "b")`, "bbb"},
+		{`starts_with("monkey", "mon")`, true},
+		{`ends_with("monkey", "mon")`, false},
+		{`contains("monkey", "key")`, true},
+		{`contains([1, [2]], [2])`, true},
+		{`contains([1, 2], 3)`, false},
+		{`index_of("日本語", "語")`, 2},
+		{`index_of("abc", "z")`, -1},
+		{`index_of([1, 2, 3], 3)`, 2},
+		{`repeat("ab", 3)`, "ababab"},
+		{`pad_left("7", 3, "0")`, "007"},
+		{`pad_right("é", 3)`, "é  "},
+		{`pad_left("long", 2)`, "long"},
+		{`pad_left("x", 4, "ab")`, "abax"},
+		{`join([1], "")`, "argument 1 to `join` must contain only STRING, got INTEGER"},
+		{`upper(1)`, "argument 1 to `upper` must be STRING, got INTEGER"},
+		{`repeat("a", -1)`, "negative repeat count: -1"},
+		{`repeat("ab", 10000000000)`, "repeat count too large: 10000000000"},
+		{`pad_left("x", 10000000000)`, "width for `pad_left` too large: 10000000000"},
+		{`pad_right("x", 10000000000, "ab")`, "width for `pad_right` too large: 10000000000"},
+		{`len(pad_right("x", 5, "abcdefghijklmnopqrstuvwxyz"))`, 5},
+		{`split("a")`, "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringIndexAndSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"héllo"[1]`, "é"},
+		{`"abc"[3]`, nil},
+		{`"abc"[-1]`, "c"},
+		{`"abc"[-4]`, nil},
+		{`"héllo"[1:3]`, "él"},
+		{`"héllo"[:2]`, "hé"},
+		{`"héllo"[3:]`, "lo"},
+		{`"abc"[:]`, "abc"},
+		{`"héllo"[:-1]`, "héll"},
+		{`"héllo"[-2:]`, "lo"},
+		{`"abc"[-4:]`, "slice bounds out of range [-4:3] with length 3"},
+		{`"abc"[2:1]`, "slice bounds out of range [2:1] with length 3"},
+		{`"abc"[0:4]`, "slice bounds out of range [0:4] with length 3"},
+		{`len([1, 2, 3, 4][1:3])`, 2},
+		{`[1, 2, 3][1:][0]`, 2},
+		{`[1, 2, 3][:-1] == [1, 2]`, true},
+		{`[1, 2, 3][-2:-1] == [2]`, true},
+		{`"abc"["a":]`, "slice index must be INTEGER, got STRING"},
+		{`1[0:1]`, "slice operator not supported: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+// testObject checks obj against expected, which is an int, a float64, a
+// bool, a string, nil for NULL, or, for an error, its message as a string.
+func testObject(t *testing.T, input string, obj object.Object, expected interface{}) {
+	t.Helper()
+
+	switch exp := expected.(type) {
+	case int:
+		testIntObj(t, obj, int64(exp))
+	case float64:
+		f, ok := obj.(*object.Float)
+		if !ok {
+			t.Errorf("%s: object is not Float. got=%T (%+v)", input, obj, obj)
+		} else if math.Abs(f.Value-exp) > 1e-9 {
+			t.Errorf("%s: wrong float. want=%v, got=%v", input, exp, f.Value)
+		}
+	case bool:
+		testBoolObj(t, obj, exp)
+	case nil:
+		testNullObj(t, obj)
+	case string:
+		switch obj := obj.(type) {
+		case *object.String:
+			if obj.Value != exp {
+				t.Errorf("%s: wrong string. want=%q, got=%q", input, exp, obj.Value)
+			}
+		case *object.Error:
+			if obj.Message != exp {
+				t.Errorf("%s: wrong error. want=%q, got=%q", input, exp, obj.Message)
+			}
+		default:
+			t.Errorf("%s: object is not String or Error. got=%T (%+v)", input, obj, obj)
+		}
+	}
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1.5", 1.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"7 / 2.0", 3.5},
+		{"7 / 2", 3},
+		{"2.0 * 3", 6.0},
+		{"1 < 1.5", true},
+		{"2.0 == 2", true},
+		{"0.1 + 0.2 != 0.3", true},
+		{"[1.0] == [1]", true},
+		{`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType object.ObjectType
+		expected     string
+	}{
+		{"9223372036854775807 + 1", object.BIGINT_OBJ, "9223372036854775808"},
+		{"-9223372036854775807 - 2", object.BIGINT_OBJ, "-9223372036854775809"},
+		{"4294967296 * 4294967296", object.BIGINT_OBJ, "18446744073709551616"},
+		{"-(-9223372036854775807 - 1)", object.BIGINT_OBJ, "9223372036854775808"},
+		{"100000000000000000000", object.BIGINT_OBJ, "100000000000000000000"},
+		{"100000000000000000000 - 99999999999999999999", object.INTEGER_OBJ, "1"},
+		{"(9223372036854775807 + 1) / 2", object.INTEGER_OBJ, "4611686018427387904"},
+		{"9223372036854775808 > 9223372036854775807", object.BOOLEAN_OBJ, "true"},
+		{"9223372036854775808 == 9223372036854775807 + 1", object.BOOLEAN_OBJ, "true"},
+		{"9223372036854775808 * 0.5", object.FLOAT_OBJ, "4611686018427388000.0"},
+		{"math.pow(2, 64)", object.BIGINT_OBJ, "18446744073709551616"},
+		{"math.abs(-9223372036854775807 - 1)", object.BIGINT_OBJ, "9223372036854775808"},
+		{"math.floor(math.pow(10.0, 30))", object.BIGINT_OBJ, "1000000000000000019884624838656"},
+		{"math.lcm(9223372036854775807, 2)", object.BIGINT_OBJ, "18446744073709551614"},
+		{`{18446744073709551616: "a"}[math.pow(2, 64)]`, object.STRING_OBJ, "a"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() != tt.expectedType || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: want %s %s, got %s %s", tt.input, tt.expectedType, tt.expected,
+				evaluated.Type(), evaluated.Inspect())
+		}
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"math.abs(-3)", 3},
+		{"math.abs(-2.5)", 2.5},
+		{"math.min(3, 1.5, 2)", 1.5},
+		{"math.max(3, 1.5, 2)", 3},
+		{"math.pow(2, 10)", 1024},
+		{"math.pow(2, -1)", 0.5},
+		{"math.pow(10, 10000000000)", "exponent too large: 10000000000"},
+		{"math.pow(-1, 10000000001)", -1},
+		{"math.pow(10, 1000) > math.pow(10, 999)", true},
+		{"math.pow(4, 0.5)", 2.0},
+		{"math.sqrt(16)", 4.0},
+		{"math.floor(2.7)", 2},
+		{"math.floor(-2.5)", -3},
+		{"math.ceil(2.1)", 3},
+		{"math.round(2.5)", 3},
+		{"math.round(7)", 7},
+		{"math.log(math.E)", 1.0},
+		{"math.log(8, 2)", 3.0},
+		{"math.sin(0)", 0.0},
+		{"math.cos(math.PI)", -1.0},
+		{"math.atan2(1, 1) * 4", math.Pi},
+		{"math.clamp(15, 0, 10)", 10},
+		{"math.clamp(-1.5, 0, 10)", 0},
+		{"math.clamp(2.5, 0, 10)", 2.5},
+		{"math.gcd(-12, 18)", 6},
+		{"math.lcm(4, 6)", 12},
+		{"let m = math; m.PI > 3", true},
+		{"let sqrt = math.sqrt; sqrt(4.0)", 2.0},
+		{"math.sqrt(-1)", "math domain error: `math.sqrt` of -1"},
+		{"math.log(0)", "math domain error: `math.log` of 0"},
+		{"math.asin(2)", "math domain error: `math.asin` of 2"},
+		{`math.abs("x")`, "argument 1 to `math.abs` must be INTEGER or FLOAT, got STRING"},
+		{"math.gcd(1.5, 2)", "argument 1 to `math.gcd` must be INTEGER, got FLOAT"},
+		{"math.clamp(1, 2, 0)", "`math.clamp` lower bound 2 is greater than upper bound 0"},
+		{"math.nope", "module math has no member nope"},
+		{"5.x", "member access not supported: INTEGER"},
+		{"math.floor(1.0 / 0)", "cannot convert +Inf to INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestArrayLit(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 	eval := testEval(input)
@@ -425,6 +1414,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
@@ -439,3 +1436,231 @@ func TestArrayIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   int64
+	}{
+		{
+			`let newAdder = fn(x) { fn(y) { x + y }; };
+			let addTwo = newAdder(2);
+			addTwo(2);`,
+			4,
+		},
+		{
+			`let adder = fn(x) { fn(y) { fn(z) { x + y + z } } };
+			adder(1)(2)(3);`,
+			6,
+		},
+		{
+			`let counter = fn(start) {
+				let next = fn(n) { fn() { let value = n + 1; value } };
+				next(start);
+			};
+			let c = counter(41);
+			c();`,
+			42,
+		},
+		{
+			`let curry = fn(f) { fn(a) { fn(b) { f(a, b) } } };
+			let mul = fn(a, b) { a * b };
+			curry(mul)(6)(7);`,
+			42,
+		},
+		{
+			`let countdown = fn(n) {
+				let loop = fn(i, acc) {
+					if (i == 0) { return acc; }
+					loop(i - 1, acc + i);
+				};
+				loop(n, 0);
+			};
+			countdown(10);`,
+			55,
+		},
+		{
+			`let outer = fn() {
+				let get = fn() { later };
+				let later = 7;
+				get();
+			};
+			outer();`,
+			7,
+		},
+		{
+			`let x = 10;
+			let f = fn() { let x = x + 1; x };
+			f() + x;`,
+			21,
+		},
+		{
+			`let f = fn(a) {
+				if (a > 0) { let b = a * 2; }
+				b;
+			};
+			f(4);`,
+			8,
+		},
+		{
+			`let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+			fib(15);`,
+			610,
+		},
+	}
+
+	for _, tt := range tests {
+		testIntObj(t, testEval(tt.input), tt.exp)
+	}
+}
+
+func TestBuiltinInsideFunction(t *testing.T) {
+	testIntObj(t, testEval(`let f = fn(s) { len(s) }; f("four")`), 4)
+
+	eval := testEval(`let f = fn() { missing }; f()`)
+	errObj, ok := eval.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", eval, eval)
+	}
+	if errObj.Message != "identifier not found: missing" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	eval := testEval(input)
+	result, ok := eval.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", eval, eval)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+
+		testIntObj(t, pair.Value, expectedValue)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntObj(t, evaluated, int64(integer))
+		} else {
+			testNullObj(t, evaluated)
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{`"a" == "b"`, false},
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] != [1, 2]`, false},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, [2, "x"]] == [1, [2, "x"]]`, true},
+		{`[1, 2] == [1, 2, 3]`, false},
+		{`{"a": [1], "b": 2} == {"b": 2, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`1 == "1"`, false},
+		{`[] == {}`, false},
+		{`let f = fn() { 1 }; f == f`, true},
+		{`fn() { 1 } == fn() { 1 }`, false},
+		{`struct N { v, next }; let a = N(1, 0); a.next = a; let b = N(1, 0); b.next = b; a == b`, true},
+		{`struct N { v, next }; let a = N(1, 0); a.next = a; let b = N(2, 0); b.next = b; a == b`, false},
+		{`struct N { v, next }; let a = N(1, 0); let b = N(1, a); a.next = b; let c = N(1, 0); c.next = c; a == c`, true},
+	}
+
+	for _, tt := range tests {
+		testBoolObj(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"abc" > "abd"`, false},
+		{`"ab" < "abc"`, true},
+		{`"Z" < "a"`, true},
+		{`[1, 2] < [1, 3]`, true},
+		{`[1, 2] > [1, 2]`, false},
+		{`[1, 2] < [1, 2, 0]`, true},
+		{`[["b"]] > [["a", "z"]]`, true},
+	}
+
+	for _, tt := range tests {
+		testBoolObj(t, testEval(tt.input), tt.expected)
+	}
+
+	errTests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1] < ["a"]`, "cannot compare INTEGER with STRING"},
+		{`{"a": 1} < {"a": 2}`, "unknown operator: HASH < HASH"},
+		{`[1] + [2]`, "unknown operator: ARRAY + ARRAY"},
+		{`{"name": "Monkey"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+	}
+
+	for _, tt := range errTests {
+		eval := testEval(tt.input)
+		errObj, ok := eval.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, eval, eval)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message for %q. want=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
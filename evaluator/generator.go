@@ -0,0 +1,177 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"runtime"
+	"sync"
+)
+
+// generatorKey is the name under which the environment of a generator's
+// body holds the generator, for yield to find. No identifier can clash
+// with it.
+const generatorKey = "<generator>"
+
+// generator runs the body of a generator function in a goroutine of its
+// own, one value at a time: next resumes the body and waits for it to
+// yield or finish.
+type generator struct {
+	fn  *object.Function
+	env *object.Environment
+
+	resume chan struct{}
+	values chan object.Object
+	stop   chan struct{}
+
+	// stopped is what yield returns once the generator is stopped, to
+	// unwind the body.
+	stopped *object.Error
+
+	mu      sync.Mutex
+	started bool
+	done    bool
+}
+
+func (g *generator) Type() object.ObjectType { return "GENERATOR" }
+func (g *generator) Inspect() string         { return "generator of " + g.fn.Inspect() }
+
+// newGenerator returns an iterator over the values the body of fn yields
+// when evaluated in env. The body does not start until the first value is
+// asked for.
+func newGenerator(fn *object.Function, env *object.Environment) object.Object {
+	g := &generator{
+		fn:      fn,
+		env:     env,
+		resume:  make(chan struct{}),
+		values:  make(chan object.Object),
+		stop:    make(chan struct{}),
+		stopped: newError("generator stopped"),
+	}
+	env.Set(generatorKey, g)
+
+	it := &object.Iterator{Next: g.next, Stop: g.halt}
+	// An iterator dropped before its end would otherwise leave the body
+	// waiting in yield forever.
+	runtime.SetFinalizer(it, func(*object.Iterator) { g.halt() })
+	return it
+}
+
+func (g *generator) next() object.Object {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.done {
+		return nil
+	}
+	if g.started {
+		g.resume <- struct{}{}
+	} else {
+		g.started = true
+		go g.run()
+	}
+
+	val := <-g.values
+	if val == nil || isError(val) {
+		g.done = true
+	}
+	return val
+}
+
+func (g *generator) run() {
+	// A panic here would not reach the recover in evalProgram, which
+	// runs in another goroutine. Once halted, nothing receives.
+	defer func() {
+		if r := recover(); r != nil {
+			select {
+			case g.values <- internalError(r):
+			case <-g.stop:
+			}
+		}
+	}()
+
+	result := eval(g.fn.Body, g.env)
+	if result == g.stopped {
+		return
+	}
+	if isError(result) {
+		g.values <- result
+		return
+	}
+	g.values <- nil
+}
+
+// halt stops the body at the yield it is waiting in.
+func (g *generator) halt() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.started && !g.done {
+		close(g.stop)
+	}
+	g.done = true
+}
+
+func evalYieldExpr(node *ast.YieldExpr, env *object.Environment) object.Object {
+	val := eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	obj, _ := env.Get(generatorKey)
+	g, ok := obj.(*generator)
+	if !ok {
+		return positioned(newError("yield outside a generator"), node.Token)
+	}
+
+	g.values <- val
+	select {
+	case <-g.resume:
+		return NULL
+	case <-g.stop:
+		return g.stopped
+	}
+}
+
+// evalForStatement runs the body of a for loop once for each value of the
+// iterable, stopping the iterator if the loop ends early.
+func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	it, ok, err := iterate(iterable)
+	if !ok {
+		return positioned(newError("cannot iterate over %s", iterable.Type()), node.Token)
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		val := it.Next()
+		if val == nil {
+			return nil
+		}
+		if isError(val) {
+			return val
+		}
+
+		mismatch, err := destructure(node.Target, val, env)
+		if err != nil {
+			it.Close()
+			return err
+		}
+		if mismatch != "" {
+			it.Close()
+			return positioned(newError("cannot destructure %s: %s", val.Inspect(), mismatch), node.Token)
+		}
+
+		result := eval(node.Body, env)
+		if result != nil {
+			if rt := result.Type(); rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				it.Close()
+				return result
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"monkey/object"
+)
+
+// addInt, subInt and mulInt return the result of an int64 operation and
+// whether it is exact; on overflow the caller redoes it with big.Ints.
+func addInt(a, b int64) (int64, bool) {
+	r := a + b
+	return r, (r > a) == (b > 0)
+}
+
+func subInt(a, b int64) (int64, bool) {
+	r := a - b
+	return r, (r < a) == (b > 0)
+}
+
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	r := a * b
+	if r/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return r, false
+	}
+	return r, true
+}
+
+// evalBigInfix evaluates an operator on two integers at least one of
+// which is a BigInt, or whose int64 result overflowed.
+func evalBigInfix(op string, left, right object.Object) object.Object {
+	leftVal, _ := object.ToBig(left)
+	rightVal, _ := object.ToBig(right)
+
+	switch op {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.NewInteger(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.NewInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return object.NewInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero")
+		}
+		return object.NewInteger(new(big.Int).Rem(leftVal, rightVal))
+	case "<<":
+		n, err := shiftCount(right)
+		if err != nil {
+			return err
+		}
+		return object.NewInteger(new(big.Int).Lsh(leftVal, n))
+	case ">>":
+		n, err := shiftCount(right)
+		if err != nil {
+			return err
+		}
+		return object.NewInteger(new(big.Int).Rsh(leftVal, n))
+	case "<":
+		return nativeBoolToBoolObj(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBoolObj(leftVal.Cmp(rightVal) > 0)
+	case "==":
+		return nativeBoolToBoolObj(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBoolObj(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+// maxShift bounds the count of a shift so that a typo such as 1 << 1000000000
+// cannot allocate an enormous BigInt.
+const maxShift = 1 << 16
+
+func shiftCount(count object.Object) (uint, *object.Error) {
+	n, ok := count.(*object.Integer)
+	if !ok || n.Value > maxShift {
+		return 0, newError("shift count too large: %s", count.Inspect())
+	}
+	if n.Value < 0 {
+		return 0, newError("negative shift count: %d", n.Value)
+	}
+	return uint(n.Value), nil
+}
+
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
@@ -0,0 +1,316 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"sync"
+)
+
+// The iterator builtins call back into the evaluator through applyFunc,
+// so they are added here rather than in the builtins literal, which
+// would otherwise depend on itself.
+func init() {
+	builtins["range"] = &object.Builtin{Fn: builtinRange}
+	builtins["take"] = &object.Builtin{Fn: builtinTake}
+	builtins["skip"] = &object.Builtin{Fn: builtinSkip}
+	builtins["map_iter"] = &object.Builtin{Fn: builtinMapIter}
+	builtins["filter_iter"] = &object.Builtin{Fn: builtinFilterIter}
+	builtins["zip"] = &object.Builtin{Fn: builtinZip}
+	builtins["enumerate"] = &object.Builtin{Fn: builtinEnumerate}
+	builtins["collect"] = &object.Builtin{Fn: builtinCollect}
+}
+
+// iterate returns an iterator over the values of obj: the elements of an
+// array or range, the characters of a string, the values of an iterator, or those
+// of what the iter method of a struct returns. ok is false for other
+// objects.
+func iterate(obj object.Object) (it *object.Iterator, ok bool, err object.Object) {
+	if result, ok := callIter(obj); ok {
+		if isError(result) {
+			return nil, true, result
+		}
+		obj = result
+	}
+
+	switch obj := obj.(type) {
+	case *object.Iterator:
+		return obj, true, nil
+	case *object.Array:
+		return sliceIterator(obj.Elements), true, nil
+	case *object.Range:
+		i, n := int64(0), obj.Len()
+		return lazy(func() object.Object {
+			if i >= n {
+				return nil
+			}
+			i++
+			return obj.At(i - 1)
+		}), true, nil
+	case *object.String:
+		chars := []object.Object{}
+		for _, r := range obj.Value {
+			chars = append(chars, &object.String{Value: string(r)})
+		}
+		return sliceIterator(chars), true, nil
+	}
+	return nil, false, nil
+}
+
+// lazy returns an iterator whose values come from next. Once next returns
+// nil or an error it is not called again. Stopping the iterator stops the
+// iterators in sources, which next reads from.
+func lazy(next func() object.Object, sources ...*object.Iterator) *object.Iterator {
+	var mu sync.Mutex
+	done := false
+	stop := func() {
+		done = true
+		for _, s := range sources {
+			s.Close()
+		}
+	}
+
+	return &object.Iterator{
+		Next: func() object.Object {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if done {
+				return nil
+			}
+			val := next()
+			if val == nil || isError(val) {
+				stop()
+			}
+			return val
+		},
+		Stop: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			stop()
+		},
+	}
+}
+
+func sliceIterator(elems []object.Object) *object.Iterator {
+	i := 0
+	return lazy(func() object.Object {
+		if i >= len(elems) {
+			return nil
+		}
+		i++
+		return elems[i-1]
+	})
+}
+
+// drain reads it to the end.
+func drain(it *object.Iterator) ([]object.Object, object.Object) {
+	elems := []object.Object{}
+	for {
+		val := it.Next()
+		if val == nil {
+			return elems, nil
+		}
+		if isError(val) {
+			return nil, val
+		}
+		elems = append(elems, val)
+	}
+}
+
+// iterableArg returns an iterator over the argument i (counting from 0)
+// of the builtin called name.
+func iterableArg(name string, args []object.Object, i int) (*object.Iterator, object.Object) {
+	it, ok, err := iterate(args[i])
+	if !ok {
+		return nil, newError("argument %d to `%s` must be iterable, got %s", i+1, name, args[i].Type())
+	}
+	return it, err
+}
+
+func builtinRange(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+	}
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		n, ok := arg.(*object.Integer)
+		if !ok {
+			return newError("argument %d to `range` must be INTEGER, got %s", i+1, arg.Type())
+		}
+		bounds[i] = n.Value
+	}
+
+	start, end, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+	if step == 0 {
+		return newError("range step must not be zero")
+	}
+
+	i := start
+	return lazy(func() object.Object {
+		if (step > 0 && i >= end) || (step < 0 && i <= end) {
+			return nil
+		}
+		i += step
+		return &object.Integer{Value: i - step}
+	})
+}
+
+func builtinTake(args ...object.Object) object.Object {
+	return limit("take", args, func(src *object.Iterator, n int64) func() object.Object {
+		taken := int64(0)
+		return func() object.Object {
+			if taken >= n {
+				return nil
+			}
+			taken++
+			return src.Next()
+		}
+	})
+}
+
+func builtinSkip(args ...object.Object) object.Object {
+	return limit("skip", args, func(src *object.Iterator, n int64) func() object.Object {
+		skipped := false
+		return func() object.Object {
+			for ; !skipped && n > 0; n-- {
+				if val := src.Next(); val == nil || isError(val) {
+					return val
+				}
+			}
+			skipped = true
+			return src.Next()
+		}
+	})
+}
+
+// limit checks the arguments of take and skip and builds the iterator.
+func limit(name string, args []object.Object, next func(*object.Iterator, int64) func() object.Object) object.Object {
+	if err := checkArgs(name, args, "", object.INTEGER_OBJ); err != nil {
+		return err
+	}
+	n := args[1].(*object.Integer).Value
+	if n < 0 {
+		return newError("argument 2 to `%s` must not be negative, got %d", name, n)
+	}
+	src, err := iterableArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	return lazy(next(src, n), src)
+}
+
+func builtinMapIter(args ...object.Object) object.Object {
+	if err := checkArgs("map_iter", args, "", ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("map_iter", args, 0)
+	if err != nil {
+		return err
+	}
+	fn := args[1]
+
+	return lazy(func() object.Object {
+		val := src.Next()
+		if val == nil || isError(val) {
+			return val
+		}
+		return applyFunc(fn, []object.Object{val}, nil)
+	}, src)
+}
+
+func builtinFilterIter(args ...object.Object) object.Object {
+	if err := checkArgs("filter_iter", args, "", ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("filter_iter", args, 0)
+	if err != nil {
+		return err
+	}
+	fn := args[1]
+
+	return lazy(func() object.Object {
+		for {
+			val := src.Next()
+			if val == nil || isError(val) {
+				return val
+			}
+			keep := applyFunc(fn, []object.Object{val}, nil)
+			if isError(keep) {
+				return keep
+			}
+			if isTruthy(keep) {
+				return val
+			}
+		}
+	}, src)
+}
+
+// builtinZip pairs up the values of its arguments, ending with the
+// shortest.
+func builtinZip(args ...object.Object) object.Object {
+	if err := checkArgs("zip", args, "", ""); err != nil {
+		return err
+	}
+	a, err := iterableArg("zip", args, 0)
+	if err != nil {
+		return err
+	}
+	b, err := iterableArg("zip", args, 1)
+	if err != nil {
+		a.Close()
+		return err
+	}
+
+	return lazy(func() object.Object {
+		x := a.Next()
+		if x == nil || isError(x) {
+			return x
+		}
+		y := b.Next()
+		if y == nil || isError(y) {
+			return y
+		}
+		return &object.Array{Elements: []object.Object{x, y}}
+	}, a, b)
+}
+
+func builtinEnumerate(args ...object.Object) object.Object {
+	if err := checkArgs("enumerate", args, ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("enumerate", args, 0)
+	if err != nil {
+		return err
+	}
+
+	i := int64(0)
+	return lazy(func() object.Object {
+		val := src.Next()
+		if val == nil || isError(val) {
+			return val
+		}
+		i++
+		return &object.Array{Elements: []object.Object{&object.Integer{Value: i - 1}, val}}
+	}, src)
+}
+
+func builtinCollect(args ...object.Object) object.Object {
+	if err := checkArgs("collect", args, ""); err != nil {
+		return err
+	}
+	src, err := iterableArg("collect", args, 0)
+	if err != nil {
+		return err
+	}
+
+	elems, err := drain(src)
+	if err != nil {
+		return err
+	}
+	return &object.Array{Elements: elems}
+}
@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+)
+
+// DefineMacros binds every top-level `let name = macro(...) {...}` in
+// program to env and removes those statements from the program.
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	definitions := []int{}
+
+	for i, statement := range program.Statements {
+		if isMacroDefinition(statement) {
+			addMacro(statement, env)
+			definitions = append(definitions, i)
+		}
+	}
+
+	for i := len(definitions) - 1; i >= 0; i = i - 1 {
+		definitionIndex := definitions[i]
+		program.Statements = append(
+			program.Statements[:definitionIndex],
+			program.Statements[definitionIndex+1:]...,
+		)
+	}
+}
+
+func isMacroDefinition(node ast.Statement) bool {
+	letStatement, ok := node.(*ast.LetStatement)
+	if !ok || letStatement.Name == nil {
+		return false
+	}
+
+	_, ok = letStatement.Value.(*ast.MacroLiteral)
+	return ok
+}
+
+func addMacro(stmt ast.Statement, env *object.Environment) {
+	letStatement, _ := stmt.(*ast.LetStatement)
+	macroLiteral, _ := letStatement.Value.(*ast.MacroLiteral)
+
+	macro := &object.Macro{
+		Params: macroLiteral.Parameters,
+		Env:    env,
+		Body:   macroLiteral.Body,
+	}
+
+	env.Set(letStatement.Name.Value, macro)
+}
+
+// ExpandMacros replaces every call of a macro defined in env with the
+// quoted AST the macro body returns. It stops at the first macro call
+// that fails: one with the wrong number of arguments, or whose body
+// fails or returns something other than quoted code.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, error) {
+	var err error
+
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		if err != nil {
+			return node
+		}
+
+		callExpression, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		macro, ok := isMacroCall(callExpression, env)
+		if !ok {
+			return node
+		}
+
+		name := callExpression.Function.String()
+		tok := callExpression.Token
+		if len(callExpression.Arguments) != len(macro.Params) {
+			err = fmt.Errorf("%d:%d: wrong number of arguments to macro %s: want=%d, got=%d",
+				tok.Line, tok.Column, name, len(macro.Params), len(callExpression.Arguments))
+			return node
+		}
+
+		args := quoteArgs(callExpression)
+		evalEnv := extendMacroEnv(macro, args)
+
+		evaluated := unwrapReturnVal(Eval(macro.Body, evalEnv))
+		switch evaluated := evaluated.(type) {
+		case *object.Quote:
+			return evaluated.Node
+		case *object.Error:
+			err = fmt.Errorf("%d:%d: macro %s failed: %s", tok.Line, tok.Column, name, evaluated.Message)
+		case nil:
+			err = fmt.Errorf("%d:%d: macro %s must return quoted code, got nothing", tok.Line, tok.Column, name)
+		default:
+			err = fmt.Errorf("%d:%d: macro %s must return quoted code, got %s", tok.Line, tok.Column, name, evaluated.Type())
+		}
+		return node
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+func isMacroCall(exp *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	identifier, ok := exp.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(identifier.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		return nil, false
+	}
+
+	return macro, true
+}
+
+func quoteArgs(exp *ast.CallExpression) []*object.Quote {
+	args := []*object.Quote{}
+
+	for _, a := range exp.Arguments {
+		args = append(args, &object.Quote{Node: ast.Copy(a)})
+	}
+
+	return args
+}
+
+func extendMacroEnv(macro *object.Macro, args []*object.Quote) *object.Environment {
+	extended := object.NewEnclosedEnv(macro.Env)
+
+	for paramIdx, param := range macro.Params {
+		extended.Set(param.Value, args[paramIdx])
+	}
+
+	return extended
+}
@@ -0,0 +1,208 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	if _, ok := env.Get("number"); ok {
+		t.Fatalf("number should not be defined")
+	}
+	if _, ok := env.Get("function"); ok {
+		t.Fatalf("function should not be defined")
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment.")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+
+	if len(macro.Params) != 2 {
+		t.Fatalf("wrong number of macro parameters. got=%d", len(macro.Params))
+	}
+
+	if macro.Params[0].String() != "x" || macro.Params[1].String() != "y" {
+		t.Fatalf("parameters wrong. got=%v", macro.Params)
+	}
+
+	expectedBody := "(x + y)"
+
+	if macro.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+			let infixExpression = macro() { quote(1 + 2); };
+
+			infixExpression();
+			`,
+			`(1 + 2)`,
+		},
+		{
+			`
+			let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+
+			reverse(2 + 2, 10 - 5);
+			`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`
+			let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+
+			unless(10 > 5, puts("not greater"), puts("greater"));
+			`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("ExpandMacros failed: %s", err)
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestEvalExpandedMacros(t *testing.T) {
+	input := `
+	let unless = macro(condition, consequence, alternative) {
+		quote(if (!(unquote(condition))) {
+			unquote(consequence);
+		} else {
+			unquote(alternative);
+		});
+	};
+
+	unless(10 > 5, 1, 2);
+	`
+
+	program := testParseProgram(input)
+	macroEnv := object.NewEnvironment()
+	DefineMacros(program, macroEnv)
+	expanded, err := ExpandMacros(program, macroEnv)
+	if err != nil {
+		t.Fatalf("ExpandMacros failed: %s", err)
+	}
+
+	testIntObj(t, Eval(expanded, object.NewEnvironment()), 2)
+}
+
+func TestResolveSplicedArgument(t *testing.T) {
+	// e is spliced into two scopes, each of which gives x a slot of its
+	// own.
+	input := `
+	let m = macro(e) { quote(fn(y) { unquote(e) }(100) + unquote(e)) };
+	let f = fn(x) { m(x) };
+	f(5)
+	`
+
+	program := testParseProgram(input)
+	macroEnv := object.NewEnvironment()
+	DefineMacros(program, macroEnv)
+	expanded, err := ExpandMacros(program, macroEnv)
+	if err != nil {
+		t.Fatalf("ExpandMacros failed: %s", err)
+	}
+	Resolve(expanded)
+
+	testIntObj(t, Eval(expanded, object.NewEnvironment()), 10)
+}
+
+func TestExpandMacroTwice(t *testing.T) {
+	input := `
+	let double = macro(x) { quote(unquote(x) * 2) };
+	let a = double(3);
+	let b = double(10);
+	[a, b]
+	`
+
+	program := testParseProgram(input)
+	macroEnv := object.NewEnvironment()
+	DefineMacros(program, macroEnv)
+	expanded, err := ExpandMacros(program, macroEnv)
+	if err != nil {
+		t.Fatalf("ExpandMacros failed: %s", err)
+	}
+
+	expected := "let a = (3 * 2);let b = (10 * 2);[a, b]"
+	if expanded.String() != expected {
+		t.Errorf("not equal. want=%q, got=%q", expected, expanded.String())
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let m = macro(a) { 5 }; m(1)", "1:26: macro m must return quoted code, got INTEGER"},
+		{"let m = macro(a, b) { quote(1) }; m(1)", "1:36: wrong number of arguments to macro m: want=2, got=1"},
+		{"let m = macro() { quote(1) }; m(1)", "1:32: wrong number of arguments to macro m: want=0, got=1"},
+		{"let m = macro(a) { 1 / 0 }; m(1)", "1:30: macro m failed: division by zero"},
+		{"let m = macro(a) { quote(unquote([1, 2])) }; m(1)", "1:47: macro m failed: cannot unquote ARRAY"},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+
+		_, err := ExpandMacros(program, env)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: wrong error. want=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
@@ -0,0 +1,376 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"monkey/ast"
+	"monkey/object"
+	"strconv"
+)
+
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+	if v, ok := subject.(*object.Variant); ok {
+		if err := checkExhaustive(node, v.Def.Enum, env); err != nil {
+			return err
+		}
+	}
+
+	// The arms with array patterns share one reading of the subject.
+	var seq *sequence
+	for _, arm := range node.Arms {
+		scope := newArmScope(arm.Pattern, env)
+		var mismatch string
+		var err object.Object
+		if pattern, ok := arm.Pattern.(*ast.ArrayPattern); ok {
+			if seq == nil {
+				seq = newSequence(subject)
+			}
+			mismatch, err = destructureSequence(pattern, seq, scope.env)
+		} else {
+			mismatch, err = destructure(arm.Pattern, subject, scope.env)
+		}
+		if err != nil {
+			return err
+		}
+
+		if mismatch == "" && arm.Guard != nil {
+			guard := eval(arm.Guard, scope.env)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				mismatch = "guard is false"
+			}
+		}
+
+		if mismatch != "" {
+			scope.undo()
+			continue
+		}
+		scope.commit()
+		return eval(arm.Body, env)
+	}
+
+	return positioned(newError("no match arm matches %s", subject.Inspect()), node.Token)
+}
+
+// armScope keeps the bindings of a match arm from env until the arm is
+// taken, so that an arm that does not match, or whose guard is false,
+// leaves the variables of env as they were. Names the resolver gave no
+// slot are bound in an environment of their own and copied into env on
+// commit; slots, which the guard reads at fixed depths, are bound in env
+// and restored on undo.
+type armScope struct {
+	env    *object.Environment
+	outer  *object.Environment
+	idents []*ast.Identifier
+	saved  []object.Object
+}
+
+func newArmScope(pattern ast.Pattern, env *object.Environment) *armScope {
+	s := &armScope{env: env, outer: env, idents: patternNames(pattern, nil)}
+	for _, ident := range s.idents {
+		if ident.Local == nil {
+			s.env = object.NewEnclosedEnv(env)
+			return s
+		}
+	}
+
+	s.saved = make([]object.Object, len(s.idents))
+	for i, ident := range s.idents {
+		s.saved[i], _ = env.GetAt(0, ident.Local.Slot)
+	}
+	return s
+}
+
+func (s *armScope) commit() {
+	if s.env == s.outer {
+		return
+	}
+	for _, ident := range s.idents {
+		if val, ok := s.env.Get(ident.Value); ok {
+			s.outer.Set(ident.Value, val)
+		}
+	}
+}
+
+func (s *armScope) undo() {
+	if s.env != s.outer {
+		return
+	}
+	for i, ident := range s.idents {
+		s.outer.SetAt(ident.Local.Slot, s.saved[i])
+	}
+}
+
+// patternNames appends the names pattern binds to names.
+func patternNames(pattern ast.Pattern, names []*ast.Identifier) []*ast.Identifier {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		names = append(names, pattern.Name)
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			names = patternNames(el, names)
+		}
+		if pattern.Rest != nil {
+			names = patternNames(pattern.Rest, names)
+		}
+	case *ast.HashPattern:
+		for _, val := range pattern.Values {
+			names = patternNames(val, names)
+		}
+	case *ast.VariantPattern:
+		for _, arg := range pattern.Args {
+			names = patternNames(arg, names)
+		}
+	}
+	return names
+}
+
+// destructure binds the names in pattern to the parts of val in env. If
+// val does not have the shape of pattern it returns a description of the
+// mismatch instead; err is only set for errors evaluating the pattern.
+func destructure(pattern ast.Pattern, val object.Object, env *object.Environment) (mismatch string, err object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return "", nil
+	case *ast.BindingPattern:
+		bind(pattern.Name, val, env)
+		return "", nil
+	case *ast.LiteralPattern:
+		lit := eval(pattern.Value, env)
+		if isError(lit) {
+			return "", lit
+		}
+		if !object.Equal(lit, val) {
+			return fmt.Sprintf("expected %s, got %s", lit.Inspect(), val.Inspect()), nil
+		}
+		return "", nil
+	case *ast.ArrayPattern:
+		return destructureArray(pattern, val, env)
+	case *ast.HashPattern:
+		return destructureHash(pattern, val, env)
+	case *ast.VariantPattern:
+		return destructureVariant(pattern, val, env)
+	default:
+		return "", newError("unknown pattern: %T", pattern)
+	}
+}
+
+func destructureArray(pattern *ast.ArrayPattern, val object.Object, env *object.Environment) (string, object.Object) {
+	return destructureSequence(pattern, newSequence(val), env)
+}
+
+func destructureSequence(pattern *ast.ArrayPattern, seq *sequence, env *object.Environment) (string, object.Object) {
+	if seq.err != nil {
+		return "", seq.err
+	}
+	if !seq.ok {
+		return fmt.Sprintf("expected an array, got %s", seq.typ), nil
+	}
+
+	// Trailing elements with defaults may be missing.
+	max := len(pattern.Elements)
+	min := max
+	for min > 0 && defaultOf(pattern.Elements[min-1]) != nil {
+		min--
+	}
+
+	// Without a rest pattern, one element past max tells that there are
+	// too many; a rest pattern takes all the others, unless it is _.
+	want := max + 1
+	if _, ok := pattern.Rest.(*ast.WildcardPattern); ok {
+		want = max
+	} else if pattern.Rest != nil {
+		want = math.MaxInt
+	}
+	n, err := seq.read(want)
+	if err != nil {
+		return "", err
+	}
+	got := strconv.Itoa(n)
+	if n > max {
+		if count, ok := seq.count(); ok {
+			got = strconv.Itoa(count)
+		} else {
+			got = fmt.Sprintf("more than %d", max)
+		}
+	}
+
+	switch {
+	case pattern.Rest != nil && n < min:
+		return fmt.Sprintf("expected at least %d elements, got %s", min, got), nil
+	case pattern.Rest == nil && min == max && n != max:
+		return fmt.Sprintf("expected %d elements, got %s", max, got), nil
+	case pattern.Rest == nil && (n < min || n > max):
+		return fmt.Sprintf("expected %d to %d elements, got %s", min, max, got), nil
+	}
+
+	for i, el := range pattern.Elements {
+		if i >= n {
+			if err := bindDefault(el, env); err != nil {
+				return "", err
+			}
+			continue
+		}
+		mismatch, err := destructure(el, seq.at(i), env)
+		if err != nil || mismatch != "" {
+			return mismatch, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		var rest []object.Object
+		for i := max; i < n; i++ {
+			rest = append(rest, seq.at(i))
+		}
+		return destructure(pattern.Rest, &object.Array{Elements: rest}, env)
+	}
+	return "", nil
+}
+
+// sequence reads the elements of a value that array patterns take apart
+// as the patterns need them, so that a pattern reads no further into a
+// long range or an iterator than it looks. Like elements, it takes the
+// elements of a struct from its iter method.
+type sequence struct {
+	typ object.ObjectType
+	ok  bool
+	err object.Object
+
+	elems []object.Object
+	rng   *object.Range
+	// it is the iterator elems are read from, nil once it has ended.
+	it *object.Iterator
+}
+
+func newSequence(val object.Object) *sequence {
+	seq := &sequence{typ: val.Type()}
+	if result, ok := callIter(val); ok {
+		if isError(result) {
+			seq.err = result
+			return seq
+		}
+		val = result
+	}
+
+	switch val := val.(type) {
+	case *object.Array:
+		seq.ok, seq.elems = true, val.Elements
+	case *object.Range:
+		seq.ok, seq.rng = true, val
+	case *object.Iterator:
+		seq.ok, seq.it = true, val
+	}
+	return seq
+}
+
+// read reads up to n elements and returns how many there are, at most n.
+func (seq *sequence) read(n int) (int, object.Object) {
+	if seq.rng != nil {
+		if size := seq.rng.Len(); int64(n) > size {
+			return int(size), nil
+		}
+		return n, nil
+	}
+
+	for len(seq.elems) < n && seq.it != nil {
+		val := seq.it.Next()
+		switch {
+		case val == nil:
+			seq.it = nil
+		case isError(val):
+			seq.it, seq.err = nil, val
+			return 0, val
+		default:
+			seq.elems = append(seq.elems, val)
+		}
+	}
+	return min(len(seq.elems), n), nil
+}
+
+// count returns the number of elements, if it is known without reading
+// any further.
+func (seq *sequence) count() (int, bool) {
+	if seq.rng != nil {
+		return int(seq.rng.Len()), true
+	}
+	return len(seq.elems), seq.it == nil
+}
+
+// at returns the element at index i, which must have been read.
+func (seq *sequence) at(i int) object.Object {
+	if seq.rng != nil {
+		return seq.rng.At(int64(i))
+	}
+	return seq.elems[i]
+}
+
+func destructureHash(pattern *ast.HashPattern, val object.Object, env *object.Environment) (string, object.Object) {
+	hash, ok := val.(*object.Hash)
+	if !ok {
+		return fmt.Sprintf("expected a hash, got %s", val.Type()), nil
+	}
+
+	for i, keyNode := range pattern.Keys {
+		key := eval(keyNode, env)
+		if isError(key) {
+			return "", key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return "", newError("unusable as hash key: %s", key.Type())
+		}
+
+		pair, ok := hash.Pairs[hashKey.HashKey()]
+		if !ok {
+			if defaultOf(pattern.Values[i]) == nil {
+				return fmt.Sprintf("missing key: %s", key.Inspect()), nil
+			}
+			if err := bindDefault(pattern.Values[i], env); err != nil {
+				return "", err
+			}
+			continue
+		}
+		mismatch, err := destructure(pattern.Values[i], pair.Value, env)
+		if err != nil || mismatch != "" {
+			return mismatch, err
+		}
+	}
+
+	return "", nil
+}
+
+func defaultOf(pattern ast.Pattern) ast.Expression {
+	if binding, ok := pattern.(*ast.BindingPattern); ok {
+		return binding.Default
+	}
+	return nil
+}
+
+func bindDefault(pattern ast.Pattern, env *object.Environment) object.Object {
+	binding := pattern.(*ast.BindingPattern)
+	val := eval(binding.Default, env)
+	if isError(val) {
+		return val
+	}
+	bind(binding.Name, val, env)
+	return nil
+}
+
+// evalDestructuringLet binds the names in the pattern of a let statement,
+// reporting a value of the wrong shape as an error.
+func evalDestructuringLet(node *ast.LetStatement, val object.Object, env *object.Environment) object.Object {
+	mismatch, err := destructure(node.Pattern, val, env)
+	if err != nil {
+		return err
+	}
+	if mismatch != "" {
+		return positioned(newError("cannot destructure %s: %s", val.Inspect(), mismatch), node.Token)
+	}
+	return nil
+}
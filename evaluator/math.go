@@ -0,0 +1,283 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"monkey/object"
+)
+
+// modules are the namespaces available to every program, looked up after
+// the builtins when an identifier is not bound. Like builtins, they are
+// only written while the package is initialized.
+var modules = map[string]*object.Module{
+	"math": {
+		Name: "math",
+		Members: map[string]object.Object{
+			"PI":    &object.Float{Value: math.Pi},
+			"E":     &object.Float{Value: math.E},
+			"abs":   &object.Builtin{Fn: mathAbs},
+			"min":   &object.Builtin{Fn: mathExtreme("min", -1)},
+			"max":   &object.Builtin{Fn: mathExtreme("max", 1)},
+			"pow":   &object.Builtin{Fn: mathPow},
+			"sqrt":  floatFunc("sqrt", math.Sqrt, func(x float64) bool { return x >= 0 }),
+			"floor": roundingFunc("floor", math.Floor),
+			"ceil":  roundingFunc("ceil", math.Ceil),
+			"round": roundingFunc("round", math.Round),
+			"log":   &object.Builtin{Fn: mathLog},
+			"sin":   floatFunc("sin", math.Sin, nil),
+			"cos":   floatFunc("cos", math.Cos, nil),
+			"tan":   floatFunc("tan", math.Tan, nil),
+			"asin":  floatFunc("asin", math.Asin, unitRange),
+			"acos":  floatFunc("acos", math.Acos, unitRange),
+			"atan":  floatFunc("atan", math.Atan, nil),
+			"atan2": &object.Builtin{Fn: mathAtan2},
+			"clamp": &object.Builtin{Fn: mathClamp},
+			"gcd":   &object.Builtin{Fn: mathGCD},
+			"lcm":   &object.Builtin{Fn: mathLCM},
+		},
+	},
+}
+
+func unitRange(x float64) bool { return -1 <= x && x <= 1 }
+
+// numberArg returns argument i of the math function name as a float64.
+func numberArg(name string, args []object.Object, i int) (float64, *object.Error) {
+	x, ok := object.ToFloat(args[i])
+	if !ok {
+		return 0, newError("argument %d to `math.%s` must be INTEGER or FLOAT, got %s", i+1, name, args[i].Type())
+	}
+	return x, nil
+}
+
+// floatFunc wraps a float64 function of one argument. If domain is not nil
+// arguments it rejects are errors rather than NaN.
+func floatFunc(name string, fn func(float64) float64, domain func(float64) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			x, err := numberArg(name, args, 0)
+			if err != nil {
+				return err
+			}
+			if domain != nil && !domain(x) {
+				return newError("math domain error: `math.%s` of %s", name, args[0].Inspect())
+			}
+			return &object.Float{Value: fn(x)}
+		},
+	}
+}
+
+// roundingFunc wraps floor, ceil and round, which leave integers alone and
+// turn floats into integers.
+func roundingFunc(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if isInteger(args[0]) {
+				return args[0]
+			}
+			x, err := numberArg(name, args, 0)
+			if err != nil {
+				return err
+			}
+			return floatToInteger(fn(x))
+		},
+	}
+}
+
+// floatToInteger converts a float with an integral value to an Integer
+// or, if it is too large, to a BigInt.
+func floatToInteger(x float64) object.Object {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return newError("cannot convert %s to INTEGER", (&object.Float{Value: x}).Inspect())
+	}
+	if math.MinInt64 <= x && x < math.MaxInt64 {
+		return &object.Integer{Value: int64(x)}
+	}
+
+	n, _ := big.NewFloat(x).Int(nil)
+	return object.NewInteger(n)
+}
+
+func mathAbs(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer, *object.BigInt:
+		n, _ := object.ToBig(arg)
+		if n.Sign() < 0 {
+			return object.NewInteger(new(big.Int).Neg(n))
+		}
+		return arg
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	default:
+		return newError("argument 1 to `math.abs` must be INTEGER or FLOAT, got %s", arg.Type())
+	}
+}
+
+// mathExtreme returns min or max: the argument that compares as sign
+// against all others, keeping its type.
+func mathExtreme(name string, sign int) func(args ...object.Object) object.Object {
+	return func(args ...object.Object) object.Object {
+		if len(args) == 0 {
+			return newError("wrong number of arguments. got=0, want at least 1")
+		}
+
+		best := args[0]
+		for i := range args {
+			if _, err := numberArg(name, args, i); err != nil {
+				return err
+			}
+			c, err := object.Compare(args[i], best)
+			if err != nil {
+				return newError("%s", err)
+			}
+			if c == sign {
+				best = args[i]
+			}
+		}
+		return best
+	}
+}
+
+// mathPow is exact for an integer raised to a non-negative integer power
+// and uses floats otherwise.
+func mathPow(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	base, ok1 := object.ToBig(args[0])
+	exp, ok2 := args[1].(*object.Integer)
+	if ok1 && ok2 && exp.Value >= 0 {
+		// Like a shift, the power of a base other than 0, 1 and -1 is
+		// bounded to maxShift bits.
+		if base.CmpAbs(big.NewInt(1)) > 0 && exp.Value > maxShift/int64(base.BitLen()) {
+			return newError("exponent too large: %d", exp.Value)
+		}
+		return object.NewInteger(new(big.Int).Exp(base, big.NewInt(exp.Value), nil))
+	}
+
+	x, err := numberArg("pow", args, 0)
+	if err != nil {
+		return err
+	}
+	y, err := numberArg("pow", args, 1)
+	if err != nil {
+		return err
+	}
+	return &object.Float{Value: math.Pow(x, y)}
+}
+
+// mathLog returns the natural logarithm of its first argument, or the
+// logarithm to the base given as the second.
+func mathLog(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	x, err := numberArg("log", args, 0)
+	if err != nil {
+		return err
+	}
+	if x <= 0 {
+		return newError("math domain error: `math.log` of %s", args[0].Inspect())
+	}
+	if len(args) == 1 {
+		return &object.Float{Value: math.Log(x)}
+	}
+
+	base, err := numberArg("log", args, 1)
+	if err != nil {
+		return err
+	}
+	if base <= 0 || base == 1 {
+		return newError("math domain error: `math.log` to base %s", args[1].Inspect())
+	}
+	return &object.Float{Value: math.Log(x) / math.Log(base)}
+}
+
+func mathAtan2(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	y, err := numberArg("atan2", args, 0)
+	if err != nil {
+		return err
+	}
+	x, err := numberArg("atan2", args, 1)
+	if err != nil {
+		return err
+	}
+	return &object.Float{Value: math.Atan2(y, x)}
+}
+
+func mathClamp(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=3", len(args))
+	}
+
+	for i := range args {
+		if _, err := numberArg("clamp", args, i); err != nil {
+			return err
+		}
+	}
+	x, lo, hi := args[0], args[1], args[2]
+
+	if c, _ := object.Compare(lo, hi); c > 0 {
+		return newError("`math.clamp` lower bound %s is greater than upper bound %s", lo.Inspect(), hi.Inspect())
+	}
+	if c, _ := object.Compare(x, lo); c < 0 {
+		return lo
+	}
+	if c, _ := object.Compare(x, hi); c > 0 {
+		return hi
+	}
+	return x
+}
+
+func integerArgs(name string, args []object.Object) (int64, int64, *object.Error) {
+	if err := checkArgs("math."+name, args, object.INTEGER_OBJ, object.INTEGER_OBJ); err != nil {
+		return 0, 0, err
+	}
+	return args[0].(*object.Integer).Value, args[1].(*object.Integer).Value, nil
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func mathGCD(args ...object.Object) object.Object {
+	a, b, err := integerArgs("gcd", args)
+	if err != nil {
+		return err
+	}
+	return object.NewInteger(new(big.Int).GCD(nil, nil, big.NewInt(a), big.NewInt(b)))
+}
+
+func mathLCM(args ...object.Object) object.Object {
+	a, b, err := integerArgs("lcm", args)
+	if err != nil {
+		return err
+	}
+	if a == 0 || b == 0 {
+		return &object.Integer{Value: 0}
+	}
+
+	lcm := new(big.Int).Mul(big.NewInt(a/gcd(a, b)), big.NewInt(b))
+	return object.NewInteger(lcm.Abs(lcm))
+}
@@ -0,0 +1,24 @@
+package evaluator
+
+import "monkey/object"
+
+// callable reports whether obj can be called.
+func callable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Builtin, *object.BoundMethod, *object.StructDef, *object.VariantDef:
+		return true
+	}
+	return false
+}
+
+// compose returns f >> g, which passes its arguments to f and the result
+// to g.
+func compose(f, g object.Object) object.Object {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		result := applyFunc(f, args, nil)
+		if isError(result) {
+			return result
+		}
+		return applyFunc(g, []object.Object{result}, nil)
+	}}
+}
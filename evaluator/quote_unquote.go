@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+)
+
+// quote splices the unquoted values into a copy of node, which belongs to
+// the program and may be quoted again with other values.
+func quote(node ast.Node, env *object.Environment) object.Object {
+	node, err := evalUnquoteCalls(ast.Copy(node), env)
+	if err != nil {
+		return err
+	}
+	return &object.Quote{Node: node}
+}
+
+// evalUnquoteCalls replaces every unquote(x) in quoted with the value of x
+// as code. It fails if x cannot be written as code.
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, object.Object) {
+	var err object.Object
+
+	node := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		if err != nil || !isUnquoteCall(node) {
+			return node
+		}
+
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != 1 {
+			return node
+		}
+
+		unquoted := eval(call.Arguments[0], env)
+		if isError(unquoted) {
+			err = unquoted
+			return node
+		}
+		converted := convertObjectToASTNode(unquoted)
+		if converted == nil && unquoted == nil {
+			err = positioned(newError("cannot unquote nothing"), call.Token)
+			return node
+		} else if converted == nil {
+			err = positioned(newError("cannot unquote %s", unquoted.Type()), call.Token)
+			return node
+		}
+		return converted
+	})
+
+	return node, err
+}
+
+func isUnquoteCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	return callExpression.Function.TokenLiteral() == "unquote"
+}
+
+func convertObjectToASTNode(obj object.Object) ast.Node {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.Token{
+			Type:    token.INT,
+			Literal: fmt.Sprintf("%d", obj.Value),
+		}
+		return &ast.IntegerLiteral{Token: t, Value: obj.Value}
+	case *object.BigInt:
+		t := token.Token{Type: token.INT, Literal: obj.Value.String()}
+		return &ast.IntegerLiteral{Token: t, Big: obj.Value}
+	case *object.Float:
+		t := token.Token{Type: token.FLOAT, Literal: obj.Inspect()}
+		return &ast.FloatLiteral{Token: t, Value: obj.Value}
+	case *object.Boolean:
+		var t token.Token
+		if obj.Value {
+			t = token.Token{Type: token.TRUE, Literal: "true"}
+		} else {
+			t = token.Token{Type: token.FALSE, Literal: "false"}
+		}
+		return &ast.Boolean{Token: t, Value: obj.Value}
+	case *object.String:
+		t := token.Token{Type: token.STRING, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}
+	case *object.Quote:
+		// The resolver records slots on the nodes themselves, so code
+		// spliced in more than once must not share them.
+		return ast.Copy(obj.Node)
+	default:
+		return nil
+	}
+}
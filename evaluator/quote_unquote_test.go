@@ -0,0 +1,85 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar)`, `foobar`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+	}
+
+	for _, tt := range tests {
+		testQuoteObj(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote(4))`, `4`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`quote(unquote(4 + 4) + 8)`, `(8 + 8)`},
+		{`let foobar = 8; quote(foobar)`, `foobar`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true))`, `true`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote("monkey"))`, `monkey`},
+		{`quote(add(unquote(1 + 1), 3))`, `add(2, 3)`},
+		{`quote(unquote(quote(4 + 4)))`, `(4 + 4)`},
+		{
+			`let quotedInfixExpression = quote(4 + 4);
+			quote(unquote(4 + 4) + unquote(quotedInfixExpression))`,
+			`(8 + (4 + 4))`,
+		},
+	}
+
+	for _, tt := range tests {
+		testQuoteObj(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestUnquoteErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"quote(unquote([1, 2]))", "cannot unquote ARRAY"},
+		{`quote(unquote({"a": 1}))`, "cannot unquote HASH"},
+		{"quote(unquote(fn(x) { x }))", "cannot unquote FUNCTION"},
+		{"quote(unquote(fn() {}()))", "cannot unquote nothing"},
+		{"quote(fn() { unquote(fn() {}()) })", "cannot unquote nothing"},
+		{"quote(1 + unquote(1 / 0))", "division by zero"},
+		{"quote()", "wrong number of arguments. got=0, want=1"},
+		{"quote(1, 2)", "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		testObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func testQuoteObj(t *testing.T, obj object.Object, expected string) {
+	quote, ok := obj.(*object.Quote)
+	if !ok {
+		t.Fatalf("expected *object.Quote. got=%T (%+v)", obj, obj)
+	}
+
+	if quote.Node == nil {
+		t.Fatalf("quote.Node is nil")
+	}
+
+	if quote.Node.String() != expected {
+		t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), expected)
+	}
+}
@@ -0,0 +1,245 @@
+package evaluator
+
+import "monkey/ast"
+
+// Resolve assigns every local variable inside the function literals of
+// node a slot in its function's environment and records on each
+// identifier how many calls up the chain and in which slot its variable
+// lives, so Eval can use indexed lookups instead of searching maps.
+//
+// Top-level names stay unresolved and are looked up by name, which keeps
+// the REPL free to add globals one line at a time. Resolve must run after
+// macro expansion; macro bodies are left alone.
+func Resolve(node ast.Node) {
+	r := &resolver{}
+	r.resolve(node)
+}
+
+type frame struct {
+	// slots holds the names declared so far; hoisted all names declared
+	// anywhere in the function body.
+	slots   map[string]int
+	hoisted map[string]int
+}
+
+type resolver struct {
+	frames []*frame
+}
+
+func (r *resolver) resolve(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			r.let(n)
+			return false
+		case *ast.StructStatement:
+			r.declare(n.Name)
+			return false
+		case *ast.EnumStatement:
+			r.declare(n.Name)
+			return false
+		case *ast.ImplStatement:
+			r.identifier(n.Target)
+			for _, m := range n.Methods {
+				r.resolve(m)
+			}
+			return false
+		case *ast.Identifier:
+			r.identifier(n)
+			return false
+		case *ast.MemberExpr:
+			r.resolve(n.Object)
+			return false
+		case *ast.NamedArgument:
+			r.resolve(n.Value)
+			return false
+		case *ast.MatchExpression:
+			r.match(n)
+			return false
+		case *ast.SelectCase:
+			r.selectCase(n)
+			return false
+		case *ast.ForStatement:
+			r.resolve(n.Iterable)
+			r.pattern(n.Target)
+			r.resolve(n.Body)
+			return false
+		case *ast.FunctionLiteral:
+			r.function(n)
+			return false
+		case *ast.MacroLiteral:
+			return false
+		}
+		return true
+	})
+}
+
+func (r *resolver) let(ls *ast.LetStatement) {
+	// A function may refer to the name it is bound to; anything else
+	// still sees an outer variable of the same name in its value.
+	if _, ok := ls.Value.(*ast.FunctionLiteral); ok {
+		r.declare(ls.Name)
+		r.resolve(ls.Value)
+		return
+	}
+
+	if ls.Value != nil {
+		r.resolve(ls.Value)
+	}
+	if ls.Pattern != nil {
+		r.pattern(ls.Pattern)
+		return
+	}
+	r.declare(ls.Name)
+}
+
+// match declares the names bound by each arm's pattern before resolving
+// its guard and body. Like let, the bindings live in the enclosing
+// function's frame.
+func (r *resolver) match(me *ast.MatchExpression) {
+	r.resolve(me.Subject)
+	for _, arm := range me.Arms {
+		r.pattern(arm.Pattern)
+		if arm.Guard != nil {
+			r.resolve(arm.Guard)
+		}
+		r.resolve(arm.Body)
+	}
+}
+
+// selectCase declares the name a receive is bound to, like a pattern
+// binding, before resolving the body.
+func (r *resolver) selectCase(sc *ast.SelectCase) {
+	if sc.Channel != nil {
+		r.resolve(sc.Channel)
+	}
+	if sc.Value != nil {
+		r.resolve(sc.Value)
+	}
+	if sc.Name != nil {
+		r.declare(sc.Name)
+	}
+	r.resolve(sc.Body)
+}
+
+// pattern declares the names bound by p in order, resolving each default
+// before the name it belongs to.
+func (r *resolver) pattern(p ast.Pattern) {
+	ast.Inspect(p, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BindingPattern:
+			if n.Default != nil {
+				r.resolve(n.Default)
+			}
+			r.declare(n.Name)
+			return false
+		case *ast.LiteralPattern:
+			r.resolve(n.Value)
+			return false
+		case *ast.VariantPattern:
+			r.identifier(n.Enum)
+			for _, arg := range n.Args {
+				r.pattern(arg)
+			}
+			return false
+		}
+		return true
+	})
+}
+
+func (r *resolver) declare(ident *ast.Identifier) {
+	if len(r.frames) == 0 {
+		return
+	}
+
+	f := r.frames[len(r.frames)-1]
+	slot := f.hoisted[ident.Value]
+	f.slots[ident.Value] = slot
+	ident.Local = &ast.Local{Depth: 0, Slot: slot}
+}
+
+func (r *resolver) identifier(ident *ast.Identifier) {
+	last := len(r.frames) - 1
+	if last < 0 {
+		return
+	}
+
+	if slot, ok := r.frames[last].slots[ident.Value]; ok {
+		ident.Local = &ast.Local{Depth: 0, Slot: slot}
+		return
+	}
+
+	// Enclosing functions have usually run to completion by the time a
+	// closure is called, so their later declarations count too.
+	for i := last - 1; i >= 0; i-- {
+		if slot, ok := r.frames[i].hoisted[ident.Value]; ok {
+			ident.Local = &ast.Local{Depth: last - i, Slot: slot}
+			return
+		}
+	}
+
+	ident.Local = nil
+}
+
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	f := &frame{slots: map[string]int{}, hoisted: map[string]int{}}
+
+	hoist := func(name string) {
+		if _, ok := f.hoisted[name]; !ok {
+			f.hoisted[name] = len(f.hoisted)
+		}
+	}
+
+	for _, p := range fn.Parameters {
+		hoist(p.Value)
+	}
+	hoistAll := func(node ast.Node) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FunctionLiteral, *ast.MacroLiteral:
+				return false
+			case *ast.LetStatement:
+				if n.Name != nil {
+					hoist(n.Name.Value)
+				}
+			case *ast.StructStatement:
+				hoist(n.Name.Value)
+				return false
+			case *ast.EnumStatement:
+				hoist(n.Name.Value)
+				return false
+			case *ast.BindingPattern:
+				hoist(n.Name.Value)
+			case *ast.SelectCase:
+				if n.Name != nil {
+					hoist(n.Name.Value)
+				}
+			}
+			return true
+		})
+	}
+	for _, d := range fn.Defaults {
+		if d != nil {
+			hoistAll(d)
+		}
+	}
+	if fn.Body != nil {
+		hoistAll(fn.Body)
+	}
+
+	// Defaults are evaluated in the call's environment and may refer to
+	// the parameters before them.
+	r.frames = append(r.frames, f)
+	for i, p := range fn.Parameters {
+		if d := fn.Default(i); d != nil {
+			r.resolve(d)
+		}
+		r.declare(p)
+	}
+	if fn.Body != nil {
+		r.resolve(fn.Body)
+	}
+	r.frames = r.frames[:len(r.frames)-1]
+
+	fn.Slots = len(f.hoisted)
+}
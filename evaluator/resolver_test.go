@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	input := `
+	let g = 1;
+	let f = fn(a, b) {
+		let c = a + g;
+		fn(d) { a + c + d + g };
+	};
+	`
+
+	program := testParseProgram(input)
+	Resolve(program)
+
+	type loc struct {
+		name  string
+		local *ast.Local
+	}
+	got := []loc{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			got = append(got, loc{ident.Value, ident.Local})
+		}
+		return true
+	})
+
+	expected := []loc{
+		{"g", nil},
+		{"f", nil},
+		{"a", &ast.Local{Depth: 0, Slot: 0}},
+		{"b", &ast.Local{Depth: 0, Slot: 1}},
+		{"c", &ast.Local{Depth: 0, Slot: 2}},
+		{"a", &ast.Local{Depth: 0, Slot: 0}},
+		{"g", nil},
+		{"d", &ast.Local{Depth: 0, Slot: 0}},
+		{"a", &ast.Local{Depth: 1, Slot: 0}},
+		{"c", &ast.Local{Depth: 1, Slot: 2}},
+		{"d", &ast.Local{Depth: 0, Slot: 0}},
+		{"g", nil},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("wrong number of identifiers. want=%d, got=%d", len(expected), len(got))
+	}
+
+	for i, want := range expected {
+		g := got[i]
+		if g.name != want.name {
+			t.Fatalf("identifier %d wrong. want=%s, got=%s", i, want.name, g.name)
+		}
+		if (g.local == nil) != (want.local == nil) {
+			t.Errorf("identifier %d (%s) resolution wrong. want=%+v, got=%+v", i, g.name, want.local, g.local)
+			continue
+		}
+		if g.local != nil && *g.local != *want.local {
+			t.Errorf("identifier %d (%s) wrong. want=%+v, got=%+v", i, g.name, *want.local, *g.local)
+		}
+	}
+
+	fn := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if fn.Slots != 3 {
+		t.Errorf("wrong number of slots. want=3, got=%d", fn.Slots)
+	}
+}
@@ -0,0 +1,167 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+func init() {
+	object.Apply = func(fn object.Object, args ...object.Object) object.Object {
+		return applyFunc(fn, args, nil)
+	}
+}
+
+func evalStructStatement(node *ast.StructStatement, env *object.Environment) object.Object {
+	def := &object.StructDef{Name: node.Name.Value}
+	for _, f := range node.Fields {
+		def.Fields = append(def.Fields, f.Value)
+	}
+	bind(node.Name, def, env)
+	return nil
+}
+
+// newStruct constructs an instance of def from the arguments of a call,
+// which give every field either in order or by name.
+func newStruct(def *object.StructDef, args []object.Object, named []namedArg) object.Object {
+	if len(args) > len(def.Fields) {
+		return arityError(len(def.Fields), len(def.Fields), len(args)+len(named))
+	}
+
+	s := &object.Struct{Def: def, Fields: make(map[string]object.Object, len(def.Fields))}
+	for i, arg := range args {
+		s.Fields[def.Fields[i]] = arg
+	}
+
+	for _, arg := range named {
+		if !hasField(def, arg.name) {
+			return newError("struct %s has no field %s", def.Name, arg.name)
+		}
+		if _, ok := s.Fields[arg.name]; ok {
+			return newError("argument %s given more than once", arg.name)
+		}
+		s.Fields[arg.name] = arg.value
+	}
+
+	for _, name := range def.Fields {
+		if _, ok := s.Fields[name]; ok {
+			continue
+		}
+		if len(named) == 0 {
+			return arityError(len(def.Fields), len(def.Fields), len(args))
+		}
+		return newError("missing field %s of struct %s", name, def.Name)
+	}
+
+	return s
+}
+
+// evalImplStatement adds the methods of an impl block to its struct.
+func evalImplStatement(node *ast.ImplStatement, env *object.Environment) object.Object {
+	target := eval(node.Target, env)
+	if isError(target) {
+		return target
+	}
+	def, ok := target.(*object.StructDef)
+	if !ok {
+		return positioned(newError("cannot impl methods on %s", target.Type()), node.Target.Token)
+	}
+
+	for i, lit := range node.Methods {
+		name := node.Names[i]
+		if hasField(def, name.Value) {
+			return positioned(newError("struct %s already has a field %s", def.Name, name.Value), name.Token)
+		}
+		if !def.SetMethod(name.Value, eval(lit, env)) {
+			return positioned(newError("cannot impl methods on frozen struct %s", def.Name), node.Target.Token)
+		}
+	}
+	return nil
+}
+
+// method returns the method called name of obj's type, if obj is a struct
+// that has one. Methods of these names are hooks the evaluator calls:
+//
+//	to_string(self)    what Inspect shows
+//	eq(self, other)    == and !=
+//	index(self, i)     self[i]
+//	iter(self)         an array or iterator of the elements, for for
+//	                   loops, ...self and array patterns
+func method(obj object.Object, name string) (object.Object, bool) {
+	s, ok := obj.(*object.Struct)
+	if !ok {
+		return nil, false
+	}
+	return s.Def.Method(name)
+}
+
+// callIter calls the iter method of obj, if it has one, checking that it
+// returns an array or an iterator.
+func callIter(obj object.Object) (object.Object, bool) {
+	iter, ok := method(obj, "iter")
+	if !ok {
+		return nil, false
+	}
+
+	result := applyFunc(iter, []object.Object{obj}, nil)
+	switch result.(type) {
+	case *object.Array, *object.Iterator, *object.Error:
+		return result, true
+	}
+	return newError("iter must return ARRAY or ITERATOR, got %s", result.Type()), true
+}
+
+// elements returns the elements of an array, a range or an iterator, or
+// of a struct with an iter method. ok is false for other objects.
+func elements(obj object.Object) (elems []object.Object, ok bool, err object.Object) {
+	if result, ok := callIter(obj); ok {
+		if isError(result) {
+			return nil, true, result
+		}
+		obj = result
+	}
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Elements, true, nil
+	case *object.Range:
+		return obj.Elements(), true, nil
+	case *object.Iterator:
+		elems, err := drain(obj)
+		return elems, true, err
+	}
+	return nil, false, nil
+}
+
+func evalAssignStatement(node *ast.AssignStatement, env *object.Environment) object.Object {
+	target := node.Target.(*ast.MemberExpr)
+
+	obj := eval(target.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	val := eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	s, ok := obj.(*object.Struct)
+	if !ok {
+		return positioned(newError("cannot assign to member %s of %s", target.Property.Value, obj.Type()), node.Token)
+	}
+	if !hasField(s.Def, target.Property.Value) {
+		return positioned(newError("struct %s has no field %s", s.Def.Name, target.Property.Value), target.Property.Token)
+	}
+	if !s.SetField(target.Property.Value, val) {
+		return positioned(newError("cannot assign to field %s of frozen %s", target.Property.Value, s.Def.Name), node.Token)
+	}
+	return nil
+}
+
+func hasField(def *object.StructDef, name string) bool {
+	for _, f := range def.Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"monkey/token"
+)
+
+// tailCall is what a call in tail position evaluates to: rather than
+// nesting another Eval for the callee, the function body returns the call
+// to applyFunc, which makes it in a loop. Tail-recursive functions thus
+// run in constant Go stack space. A tailCall never escapes applyFunc.
+type tailCall struct {
+	fn    *object.Function
+	args  []object.Object
+	named []namedArg
+	token token.Token
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call of " + tc.fn.Inspect() }
@@ -0,0 +1,182 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"reflect"
+)
+
+// evalSpawnExpr starts a task running the call of a spawn expression. The
+// function and arguments are evaluated first, in the spawning task.
+func evalSpawnExpr(node *ast.SpawnExpr, env *object.Environment) object.Object {
+	var fn object.Object
+	var args []object.Object
+	var named []namedArg
+
+	if call, ok := node.Call.(*ast.CallExpression); ok {
+		fn = eval(call.Function, env)
+		if isError(fn) {
+			return fn
+		}
+		var err object.Object
+		args, named, err = evalArguments(call.Arguments, env)
+		if err != nil {
+			return err
+		}
+	} else {
+		fn = eval(node.Call, env)
+		if isError(fn) {
+			return fn
+		}
+	}
+
+	switch fn.(type) {
+	case *object.Function, *object.BoundMethod, *object.Builtin:
+	default:
+		return positioned(newError("cannot spawn %s", fn.Type()), node.Token)
+	}
+
+	task := object.NewTask()
+	go func() {
+		// A panic here would not reach the recover in evalProgram,
+		// which runs in another goroutine.
+		defer func() {
+			if r := recover(); r != nil {
+				task.Finish(internalError(r))
+			}
+		}()
+		task.Finish(applyFunc(fn, args, named))
+	}()
+	return task
+}
+
+func builtinAwait(args ...object.Object) object.Object {
+	if err := checkArgs("await", args, object.TASK_OBJ); err != nil {
+		return err
+	}
+	return args[0].(*object.Task).Wait()
+}
+
+func builtinChannel(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+	if len(args) == 0 {
+		return object.NewChannel(0)
+	}
+
+	size, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError("argument 1 to `channel` must be INTEGER, got %s", args[0].Type())
+	}
+	if size.Value < 0 {
+		return newError("channel size must not be negative, got %d", size.Value)
+	}
+	return object.NewChannel(int(size.Value))
+}
+
+// channelMember returns the method of ch called name.
+func channelMember(ch *object.Channel, name string) object.Object {
+	switch name {
+	case "send":
+		return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("send", args, ""); err != nil {
+				return err
+			}
+			if !ch.Send(args[0]) {
+				return newError("send on closed channel")
+			}
+			return NULL
+		}}
+	case "recv":
+		return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("recv", args); err != nil {
+				return err
+			}
+			return received(<-ch.C)
+		}}
+	case "close":
+		return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("close", args); err != nil {
+				return err
+			}
+			if !ch.Close() {
+				return newError("close of closed channel")
+			}
+			return NULL
+		}}
+	}
+	return newError("channel has no method %s", name)
+}
+
+// received turns the value of a receive into an object: a closed channel
+// gives nil, which reads as null.
+func received(val object.Object) object.Object {
+	if val == nil {
+		return NULL
+	}
+	return val
+}
+
+// evalSelectExpression evaluates the channels and values of every case,
+// then performs whichever operation can go ahead first, picking at random
+// if several can.
+func evalSelectExpression(node *ast.SelectExpression, env *object.Environment) object.Object {
+	cases := make([]reflect.SelectCase, len(node.Cases))
+	for i, c := range node.Cases {
+		if c.Channel == nil {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectDefault}
+			continue
+		}
+
+		obj := eval(c.Channel, env)
+		if isError(obj) {
+			return obj
+		}
+		ch, ok := obj.(*object.Channel)
+		if !ok {
+			return positioned(newError("cannot select on %s", obj.Type()), c.Token)
+		}
+
+		if c.Value == nil {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch.C)}
+			continue
+		}
+		val := eval(c.Value, env)
+		if isError(val) {
+			return val
+		}
+		if ch.Closed() {
+			return positioned(newError("send on closed channel"), c.Token)
+		}
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch.C), Send: reflect.ValueOf(&val).Elem()}
+	}
+
+	chosen, recv, err := trySelect(cases)
+	if err != nil {
+		return positioned(err, node.Token)
+	}
+
+	c := node.Cases[chosen]
+	if c.Name != nil {
+		var val object.Object
+		if recv.IsValid() && !recv.IsNil() {
+			val = recv.Interface().(object.Object)
+		}
+		bind(c.Name, received(val), env)
+	}
+	return eval(c.Body, env)
+}
+
+// trySelect runs cases, turning the panic of a send on a channel closed
+// while waiting into an error.
+func trySelect(cases []reflect.SelectCase) (chosen int, recv reflect.Value, err *object.Error) {
+	defer func() {
+		if recover() != nil {
+			err = newError("send on closed channel")
+		}
+	}()
+
+	chosen, recv, _ = reflect.Select(cases)
+	return chosen, recv, nil
+}
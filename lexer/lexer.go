@@ -1,34 +1,102 @@
 package lexer
 
-import "monkey/token"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"monkey/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
+// Lexer turns UTF-8 encoded source into tokens. It reads its input
+// incrementally and never looks more than one rune ahead, so it can lex
+// arbitrarily large files and interactive streams. Columns count runes,
+// not bytes.
 type Lexer struct {
-	input        string
-	position     int
-	readPosition int
-	ch           byte
+	r       *bufio.Reader
+	ch      rune
+	bad     byte // the undecodable byte read as ch, if ch is utf8.RuneError
+	peeked  bool
+	next    rune
+	nextBad byte
+	done    bool // the reader returned an error, possibly io.EOF
+	line    int
+	column  int
+	errors  []string
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewReader(strings.NewReader(input))
+}
+
+// NewReader returns a Lexer that reads its input from r as it is needed.
+func NewReader(r io.Reader) *Lexer {
+	l := &Lexer{r: bufio.NewReader(r), line: 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
+	if l.peeked {
+		l.ch, l.bad = l.next, l.nextBad
+		l.peeked = false
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, l.bad = l.readRune()
+	}
+
+	if l.bad != 0 {
+		l.errorf("invalid UTF-8 byte %#x", l.bad)
+	}
+}
+
+// readRune decodes the next rune of the input. Past the end of the input
+// and after a read error it returns 0. For a byte that does not start a
+// valid encoding it returns utf8.RuneError and that byte.
+func (l *Lexer) readRune() (rune, byte) {
+	if l.done {
+		return 0, 0
+	}
+
+	ch, size, err := l.r.ReadRune()
+	if err != nil {
+		l.done = true
+		if err != io.EOF {
+			l.errorf("read error: %s", err)
+		}
+		return 0, 0
+	}
+
+	if ch == utf8.RuneError && size == 1 {
+		l.r.UnreadRune()
+		b, _ := l.r.ReadByte()
+		return ch, b
 	}
-	l.position = l.readPosition
-	l.readPosition += 1
+	return ch, 0
+}
+
+// Errors returns the lexical errors found in the input read so far.
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+func (l *Lexer) errorf(format string, a ...interface{}) {
+	msg := fmt.Sprintf("%d:%d: %s", l.line, l.column, fmt.Sprintf(format, a...))
+	l.errors = append(l.errors, msg)
 }
 
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	line, column := l.line, l.column
 
 	switch l.ch {
 	case '=':
@@ -37,13 +105,23 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.FAT_ARROW, Literal: "=>"}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -57,14 +135,35 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SLASH, l.ch)
 	case '*':
 		tok = newToken(token.ASTERISK, l.ch)
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '<' {
+			l.readChar()
+			tok = token.Token{Type: token.SHL, Literal: "<<"}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.SHR, Literal: ">>"}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.PIPE, Literal: "|>"}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -73,6 +172,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '.':
+		tok = l.readDots()
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -87,43 +188,79 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
+		} else if l.bad != 0 {
+			tok = token.Token{Type: token.ILLEGAL, Literal: string([]byte{l.bad})}
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 	l.readChar()
+	tok.Line, tok.Column = line, column
 	return tok
 }
 
+// readDots reads a single dot, a range operator .. or ..=, or an
+// ellipsis.
+func (l *Lexer) readDots() token.Token {
+	if l.peekChar() != '.' {
+		return newToken(token.DOT, l.ch)
+	}
+
+	l.readChar()
+	switch l.peekChar() {
+	case '.':
+		l.readChar()
+		return token.Token{Type: token.ELLIPSIS, Literal: "..."}
+	case '=':
+		l.readChar()
+		return token.Token{Type: token.DOTDOT_EQ, Literal: "..="}
+	}
+	return token.Token{Type: token.DOTDOT, Literal: ".."}
+}
+
+// readIdentifier reads a letter followed by any letters and digits.
 func (l *Lexer) readIdentifier() string {
-	position := l.position
-	for isLetter(l.ch) {
+	var out strings.Builder
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.writeChar(&out)
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return out.String()
 }
 
 func (l *Lexer) readString() string {
-	position := l.position + 1
+	var out strings.Builder
 	for {
 		l.readChar()
 		if l.ch == '"' || l.ch == 0 {
 			break
 		}
+		l.writeChar(&out)
 	}
-	return l.input[position:l.position]
+	return out.String()
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// writeChar appends the current character to out as it appeared in the
+// input.
+func (l *Lexer) writeChar(out *strings.Builder) {
+	if l.bad != 0 {
+		out.WriteByte(l.bad)
+	} else {
+		out.WriteRune(l.ch)
+	}
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
@@ -133,22 +270,40 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position
+// readNumber reads an integer or, if its digits are followed by a dot and
+// another digit, a float such as 1.5.
+func (l *Lexer) readNumber() (token.TokenType, string) {
+	var out strings.Builder
+	l.readDigits(&out)
+
+	if l.ch != '.' || !isDigit(l.peekChar()) {
+		return token.INT, out.String()
+	}
+
+	l.writeChar(&out)
+	l.readChar()
+	l.readDigits(&out)
+	return token.FLOAT, out.String()
+}
+
+func (l *Lexer) readDigits(out *strings.Builder) {
 	for isDigit(l.ch) {
+		l.writeChar(out)
 		l.readChar()
 	}
-	return l.input[position:l.position]
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	if l.ch == 0 {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+	if !l.peeked {
+		l.next, l.nextBad = l.readRune()
+		l.peeked = true
+	}
+	return l.next
 }
@@ -1,8 +1,12 @@
 package lexer
 
 import (
+	"errors"
+	"io"
 	"monkey/token"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNextToken(t *testing.T) {
@@ -28,6 +32,8 @@ func TestNextToken(t *testing.T) {
 	"foobar"
 	"foo bar"
 	[1, 2];
+	macro(x, y) { x + y; };
+	fn(a: int) -> int
 	`
 
 	tests := []struct {
@@ -115,6 +121,27 @@ func TestNextToken(t *testing.T) {
 		{token.INT, "2"},
 		{token.RBRACKET, "]"},
 		{token.SEMICOLON, ";"},
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.PLUS, "+"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "->"},
+		{token.IDENT, "int"},
 		{token.EOF, ""},
 	}
 
@@ -132,3 +159,208 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x + "hi";`
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.IDENT, 2, 3},
+		{token.PLUS, 2, 5},
+		{token.STRING, 2, 7},
+		{token.SEMICOLON, 2, 11},
+		{token.EOF, 2, 12},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d", i,
+				tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "let größe = \"🐒 ok\";\nπ + größe"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.LET, "let", 1, 1},
+		{token.IDENT, "größe", 1, 5},
+		{token.ASSIGN, "=", 1, 11},
+		{token.STRING, "🐒 ok", 1, 13},
+		{token.SEMICOLON, ";", 1, 19},
+		{token.IDENT, "π", 2, 1},
+		{token.PLUS, "+", 2, 3},
+		{token.IDENT, "größe", 2, 5},
+		{token.EOF, "", 2, 10},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%s %q, got=%s %q", i,
+				tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d", i,
+				tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	l := New("let é = 1;\n x \xff")
+
+	var illegal token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.ILLEGAL {
+			illegal = tok
+		}
+	}
+
+	if illegal.Literal != "\xff" || illegal.Line != 2 || illegal.Column != 4 {
+		t.Errorf("wrong ILLEGAL token. got=%+v", illegal)
+	}
+
+	expected := []string{"2:4: invalid UTF-8 byte 0xff"}
+	if len(l.Errors()) != len(expected) || l.Errors()[0] != expected[0] {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, l.Errors())
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	input := "let größe = \"🐒\";\nx != 10"
+
+	want := New(input)
+	got := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+
+	for i := 0; ; i++ {
+		expected, tok := want.NextToken(), got.NextToken()
+		if tok != expected {
+			t.Fatalf("tokens[%d] wrong. expected=%+v, got=%+v", i, expected, tok)
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+}
+
+// stopReader fails the test if the lexer reads past the input it was
+// given, as it would block on an interactive stream.
+type stopReader struct {
+	t *testing.T
+}
+
+func (r stopReader) Read(p []byte) (int, error) {
+	r.t.Fatal("lexer read past the end of the current line")
+	return 0, io.EOF
+}
+
+func TestNewReaderDoesNotReadAhead(t *testing.T) {
+	input := io.MultiReader(strings.NewReader("let x = 5;\n"), stopReader{t})
+	l := NewReader(iotest.OneByteReader(input))
+
+	expected := []token.TokenType{token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON}
+	for i, tt := range expected {
+		if tok := l.NextToken(); tok.Type != tt {
+			t.Fatalf("tokens[%d] wrong. expected=%s, got=%s", i, tt, tok.Type)
+		}
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	input := io.MultiReader(strings.NewReader("x"), iotest.ErrReader(errors.New("broken pipe")))
+	l := NewReader(input)
+
+	if tok := l.NextToken(); tok.Type != token.IDENT || tok.Literal != "x" {
+		t.Fatalf("wrong token. got=%+v", tok)
+	}
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("expected EOF. got=%+v", tok)
+	}
+
+	expected := "1:2: read error: broken pipe"
+	if len(l.Errors()) != 1 || l.Errors()[0] != expected {
+		t.Errorf("wrong errors. expected=%q, got=%q", expected, l.Errors())
+	}
+}
+
+func TestNumbersAndOperators(t *testing.T) {
+	input := "1.5 3. x.y 1...2 0.25 atan2 7 % 2 << 1 >> 1 < > => ..x 1..=2 |> |"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "1.5"},
+		{token.INT, "3"},
+		{token.DOT, "."},
+		{token.IDENT, "x"},
+		{token.DOT, "."},
+		{token.IDENT, "y"},
+		{token.INT, "1"},
+		{token.ELLIPSIS, "..."},
+		{token.INT, "2"},
+		{token.FLOAT, "0.25"},
+		{token.IDENT, "atan2"},
+		{token.INT, "7"},
+		{token.PERCENT, "%"},
+		{token.INT, "2"},
+		{token.SHL, "<<"},
+		{token.INT, "1"},
+		{token.SHR, ">>"},
+		{token.INT, "1"},
+		{token.LT, "<"},
+		{token.GT, ">"},
+		{token.FAT_ARROW, "=>"},
+		{token.DOTDOT, ".."},
+		{token.IDENT, "x"},
+		{token.INT, "1"},
+		{token.DOTDOT_EQ, "..="},
+		{token.INT, "2"},
+		{token.PIPE, "|>"},
+		{token.ILLEGAL, "|"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%s %q, got=%s %q", i,
+				tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,491 @@
+// Package lint reports likely mistakes in Monkey programs without running
+// them.
+//
+// Scopes follow the evaluator: a function body is one scope no matter how
+// many blocks it contains, and names declared later in an enclosing scope
+// are visible inside function bodies because those only run once called.
+package lint
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/token"
+	"sort"
+	"strings"
+)
+
+type Rule string
+
+const (
+	Undefined         Rule = "undefined"
+	Unused            Rule = "unused"
+	Shadow            Rule = "shadow"
+	Unreachable       Rule = "unreachable"
+	Arity             Rule = "arity"
+	ConstantCondition Rule = "constant-condition"
+)
+
+// AllRules lists every rule in the order they are documented.
+var AllRules = []Rule{Undefined, Unused, Shadow, Unreachable, Arity, ConstantCondition}
+
+// Config selects the rules a Lint run reports.
+type Config struct {
+	Rules map[Rule]bool
+}
+
+// NewConfig returns a Config with every rule enabled.
+func NewConfig() Config {
+	cfg := Config{Rules: map[Rule]bool{}}
+	for _, r := range AllRules {
+		cfg.Rules[r] = true
+	}
+	return cfg
+}
+
+func (c Config) Enable(r Rule)  { c.Rules[r] = true }
+func (c Config) Disable(r Rule) { c.Rules[r] = false }
+
+// ParseRule returns the Rule called name.
+func ParseRule(name string) (Rule, error) {
+	for _, r := range AllRules {
+		if string(r) == name {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown lint rule %q", name)
+}
+
+type Diagnostic struct {
+	Rule    Rule
+	Line    int
+	Column  int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Line, d.Column, d.Message, d.Rule)
+}
+
+type binding struct {
+	ident    *ast.Identifier
+	kind     string
+	used     bool
+	redefs   int
+	function *ast.FunctionLiteral
+}
+
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+	defined  map[string]bool
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, bindings: map[string]*binding{}, defined: map[string]bool{}}
+}
+
+type linter struct {
+	cfg         Config
+	scope       *scope
+	diagnostics []Diagnostic
+}
+
+// Lint checks program and returns the diagnostics of the rules enabled in
+// cfg, ordered by position.
+func Lint(program *ast.Program, cfg Config) []Diagnostic {
+	l := &linter{cfg: cfg, scope: newScope(nil)}
+
+	l.declareAll(program.Statements)
+	l.statements(program.Statements)
+	l.closeScope()
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return l.diagnostics
+}
+
+func (l *linter) report(rule Rule, tok token.Token, format string, a ...interface{}) {
+	if !l.cfg.Rules[rule] {
+		return
+	}
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Rule:    rule,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// declareAll records every let and pattern binding of a scope up front, so
+// that function bodies can refer to names declared after them.
+func (l *linter) declareAll(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FunctionLiteral, *ast.MacroLiteral:
+				return false
+			case *ast.LetStatement:
+				l.declare(n.Name, "variable", n.Value)
+			case *ast.StructStatement:
+				l.declare(n.Name, "struct", nil)
+				return false
+			case *ast.EnumStatement:
+				l.declare(n.Name, "enum", nil)
+				return false
+			case *ast.BindingPattern:
+				l.declare(n.Name, "variable", nil)
+			case *ast.SelectCase:
+				l.declare(n.Name, "variable", nil)
+			}
+			return true
+		})
+	}
+}
+
+func (l *linter) declare(ident *ast.Identifier, kind string, value ast.Expression) {
+	if ident == nil {
+		return
+	}
+
+	if b, ok := l.scope.bindings[ident.Value]; ok {
+		b.redefs++
+		b.function = nil
+		return
+	}
+
+	b := &binding{ident: ident, kind: kind}
+	if fn, ok := value.(*ast.FunctionLiteral); ok {
+		b.function = fn
+	}
+	l.scope.bindings[ident.Value] = b
+
+	if l.shadows(ident.Value) {
+		l.report(Shadow, ident.Token, "%s %s shadows an outer declaration", kind, ident.Value)
+	}
+}
+
+func (l *linter) shadows(name string) bool {
+	for s := l.scope.outer; s != nil; s = s.outer {
+		if _, ok := s.bindings[name]; ok {
+			return true
+		}
+	}
+	return evaluator.IsBuiltin(name)
+}
+
+func (l *linter) closeScope() {
+	for name, b := range l.scope.bindings {
+		if !b.used && !strings.HasPrefix(name, "_") {
+			l.report(Unused, b.ident.Token, "%s %s is never used", b.kind, name)
+		}
+	}
+	l.scope = l.scope.outer
+}
+
+func (l *linter) lookup(name string) (*binding, bool) {
+	if l.scope.defined[name] {
+		return l.scope.bindings[name], true
+	}
+	for s := l.scope.outer; s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func (l *linter) statements(stmts []ast.Statement) {
+	for i, stmt := range stmts {
+		if stmt == nil {
+			continue
+		}
+		l.visit(stmt)
+
+		if _, ok := stmt.(*ast.ReturnStatement); ok && i+1 < len(stmts) && stmts[i+1] != nil {
+			next := stmts[i+1]
+			l.report(Unreachable, tokenOf(next), "unreachable code after return")
+		}
+	}
+}
+
+func (l *linter) visit(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Program:
+			l.statements(n.Statements)
+			return false
+		case *ast.BlockStatement:
+			l.statements(n.Statements)
+			return false
+		case *ast.LetStatement:
+			if _, ok := n.Value.(*ast.FunctionLiteral); ok {
+				l.scope.defined[n.Name.Value] = true
+			}
+			if n.Value != nil {
+				l.visit(n.Value)
+			}
+			if n.Pattern != nil {
+				l.pattern(n.Pattern)
+				return false
+			}
+			l.scope.defined[n.Name.Value] = true
+			return false
+		case *ast.StructStatement:
+			l.scope.defined[n.Name.Value] = true
+			return false
+		case *ast.EnumStatement:
+			l.scope.defined[n.Name.Value] = true
+			return false
+		case *ast.ImplStatement:
+			l.use(n.Target)
+			for _, m := range n.Methods {
+				l.visit(m)
+			}
+			return false
+		case *ast.Identifier:
+			l.use(n)
+			return false
+		case *ast.MemberExpr:
+			l.visit(n.Object)
+			return false
+		case *ast.MatchExpression:
+			l.visit(n.Subject)
+			for _, arm := range n.Arms {
+				l.pattern(arm.Pattern)
+				if arm.Guard != nil {
+					l.visit(arm.Guard)
+				}
+				l.visit(arm.Body)
+			}
+			return false
+		case *ast.SelectCase:
+			if n.Channel != nil {
+				l.visit(n.Channel)
+			}
+			if n.Value != nil {
+				l.visit(n.Value)
+			}
+			if n.Name != nil {
+				l.scope.defined[n.Name.Value] = true
+			}
+			l.visit(n.Body)
+			return false
+		case *ast.ForStatement:
+			l.visit(n.Iterable)
+			l.pattern(n.Target)
+			l.visit(n.Body)
+			return false
+		case *ast.PipeExpr:
+			l.visit(n.Call())
+			return false
+		case *ast.NamedArgument:
+			l.visit(n.Value)
+			return false
+		case *ast.FunctionLiteral:
+			l.function(n.Parameters, n.Defaults, n.Body)
+			return false
+		case *ast.MacroLiteral:
+			l.function(n.Parameters, nil, n.Body)
+			return false
+		case *ast.IfExpression:
+			l.condition(n)
+		case *ast.CallExpression:
+			if ident, ok := n.Function.(*ast.Identifier); ok && ident.Value == "quote" {
+				l.quoted(n)
+				return false
+			}
+			l.arity(n)
+		}
+		return true
+	})
+}
+
+func (l *linter) use(ident *ast.Identifier) {
+	if b, ok := l.lookup(ident.Value); ok {
+		b.used = true
+		return
+	}
+	if evaluator.IsBuiltin(ident.Value) {
+		return
+	}
+	l.report(Undefined, ident.Token, "undefined: %s", ident.Value)
+}
+
+func (l *linter) function(params []*ast.Identifier, defaults []ast.Expression, body *ast.BlockStatement) {
+	l.scope = newScope(l.scope)
+
+	for i, p := range params {
+		if i < len(defaults) && defaults[i] != nil {
+			l.visit(defaults[i])
+		}
+		l.declare(p, "parameter", nil)
+		l.scope.defined[p.Value] = true
+	}
+	if body != nil {
+		l.declareAll(body.Statements)
+		l.statements(body.Statements)
+	}
+
+	l.closeScope()
+}
+
+// quoted only lints the arguments of unquote calls inside a quote; the rest
+// is code for another place.
+func (l *linter) quoted(call *ast.CallExpression) {
+	for _, arg := range call.Arguments {
+		ast.Inspect(arg, func(n ast.Node) bool {
+			c, ok := n.(*ast.CallExpression)
+			if !ok {
+				return true
+			}
+			if ident, ok := c.Function.(*ast.Identifier); ok && ident.Value == "unquote" {
+				for _, a := range c.Arguments {
+					l.visit(a)
+				}
+				return false
+			}
+			return true
+		})
+	}
+}
+
+func (l *linter) arity(call *ast.CallExpression) {
+	var fn *ast.FunctionLiteral
+	tok := call.Token
+
+	switch callee := call.Function.(type) {
+	case *ast.FunctionLiteral:
+		fn, tok = callee, callee.Token
+	case *ast.Identifier:
+		if b, ok := l.lookup(callee.Value); ok && b.redefs == 0 {
+			fn, tok = b.function, callee.Token
+		}
+	}
+
+	if fn == nil {
+		return
+	}
+	for _, arg := range call.Arguments {
+		if _, ok := arg.(*ast.SpreadExpr); ok {
+			return
+		}
+	}
+
+	min, max := fn.Arity()
+	got := len(call.Arguments)
+	if got >= min && (max < 0 || got <= max) {
+		return
+	}
+
+	var want string
+	switch {
+	case max < 0:
+		want = fmt.Sprintf("at least %d", min)
+	case min == max:
+		want = fmt.Sprint(min)
+	default:
+		want = fmt.Sprintf("%d to %d", min, max)
+	}
+	l.report(Arity, tok, "%s called with %d arguments, want %s",
+		call.Function.String(), got, want)
+}
+
+func (l *linter) condition(ie *ast.IfExpression) {
+	value, ok := constantTruth(ie.Condition)
+	if !ok {
+		return
+	}
+	l.report(ConstantCondition, ie.Token, "if condition is always %t", value)
+}
+
+// constantTruth reports the truthiness of exp if it does not depend on
+// anything but literals.
+func constantTruth(exp ast.Expression) (bool, bool) {
+	switch exp := exp.(type) {
+	case *ast.Boolean:
+		return exp.Value, true
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.ArrayLiteral, *ast.HashLiteral, *ast.FunctionLiteral:
+		return true, true
+	case *ast.PrefixExpression:
+		if exp.Operator != "!" {
+			return false, false
+		}
+		value, ok := constantTruth(exp.Right)
+		return !value, ok
+	case *ast.InfixExpression:
+		left, ok := exp.Left.(*ast.IntegerLiteral)
+		if !ok {
+			return false, false
+		}
+		right, ok := exp.Right.(*ast.IntegerLiteral)
+		if !ok || left.Big != nil || right.Big != nil {
+			return false, false
+		}
+		switch exp.Operator {
+		case "<":
+			return left.Value < right.Value, true
+		case ">":
+			return left.Value > right.Value, true
+		case "==":
+			return left.Value == right.Value, true
+		case "!=":
+			return left.Value != right.Value, true
+		}
+	}
+	return false, false
+}
+
+// pattern marks the names bound by p as defined, visiting each default
+// before the name it belongs to.
+func (l *linter) pattern(p ast.Pattern) {
+	ast.Inspect(p, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BindingPattern:
+			if n.Default != nil {
+				l.visit(n.Default)
+			}
+			l.scope.defined[n.Name.Value] = true
+			return false
+		case *ast.LiteralPattern:
+			return false
+		case *ast.VariantPattern:
+			l.use(n.Enum)
+			for _, arg := range n.Args {
+				l.pattern(arg)
+			}
+			return false
+		}
+		return true
+	})
+}
+
+func tokenOf(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.StructStatement:
+		return stmt.Token
+	case *ast.ImplStatement:
+		return stmt.Token
+	case *ast.EnumStatement:
+		return stmt.Token
+	case *ast.AssignStatement:
+		return stmt.Token
+	case *ast.ForStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.BlockStatement:
+		return stmt.Token
+	}
+	return token.Token{}
+}
@@ -0,0 +1,266 @@
+package lint
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func lint(t *testing.T, input string, rules ...Rule) []string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	cfg := NewConfig()
+	if len(rules) > 0 {
+		for _, r := range AllRules {
+			cfg.Disable(r)
+		}
+		for _, r := range rules {
+			cfg.Enable(r)
+		}
+	}
+
+	out := []string{}
+	for _, d := range Lint(program, cfg) {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func expectDiagnostics(t *testing.T, input string, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("wrong diagnostics for %q.\nwant=%q\ngot=%q", input, want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diagnostic %d wrong for %q. want=%q, got=%q", i, input, want[i], got[i])
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		rule     Rule
+		expected []string
+	}{
+		{
+			"let x = 1; x + y;",
+			Undefined,
+			[]string{"1:16: undefined: y (undefined)"},
+		},
+		{
+			"x; let x = 1; x",
+			Undefined,
+			[]string{"1:1: undefined: x (undefined)"},
+		},
+		{
+			"let f = fn() { g() }; let g = fn() { 1 }; f();",
+			Undefined,
+			[]string{},
+		},
+		{
+			"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5);",
+			Undefined,
+			[]string{},
+		},
+		{
+			`let m = macro(a) { quote(unquote(a) + b) }; len("");`,
+			Undefined,
+			[]string{},
+		},
+		{
+			"let r = math.sqrt(2.0); r.sqrt; q.sqrt;",
+			Undefined,
+			[]string{"1:33: undefined: q (undefined)"},
+		},
+		{
+			"let f = fn(v) { match (v) { [a, ...rest] if a > 0 => a, _ => b } }; f([1]);",
+			Undefined,
+			[]string{"1:62: undefined: b (undefined)"},
+		},
+		{
+			"let f = fn(v) { match (v) { [a, ...rest] => a, {1: _x} => 0 } }; f([1]);",
+			Unused,
+			[]string{"1:36: variable rest is never used (unused)"},
+		},
+		{
+			"let f = fn(p) { let [a, b = c, ...rest] = p; a + len(rest) }; f([1]);",
+			Undefined,
+			[]string{"1:29: undefined: c (undefined)"},
+		},
+		{
+			"let f = fn(p) { let [a, b = 1, ...rest] = p; a + len(rest) }; f([1]);",
+			Unused,
+			[]string{"1:25: variable b is never used (unused)"},
+		},
+		{
+			"let f = fn(p) { let {name, age: _age} = p; name }; f({});",
+			Unused,
+			[]string{},
+		},
+		{
+			"let f = fn() { struct P { x }; struct Q { y }; Q(1).y }; f();",
+			Unused,
+			[]string{"1:23: struct P is never used (unused)"},
+		},
+		{
+			"struct P { x }; let p = P(1); p.x = y;",
+			Undefined,
+			[]string{"1:37: undefined: y (undefined)"},
+		},
+		{
+			"type P { x }; impl P { fn get(self) { self.x + y } }; impl Q { fn f(self) { self } }",
+			Undefined,
+			[]string{"1:48: undefined: y (undefined)", "1:60: undefined: Q (undefined)"},
+		},
+		{
+			"let f = fn() { type P { x }; impl P { fn get(self, n) { self.x } } }; f();",
+			Unused,
+			[]string{"1:52: parameter n is never used (unused)"},
+		},
+		{
+			"let f = fn(r) { match (r) { Result.Ok(v) => v, Opt.None => 0 } }; enum Result { Ok(v) } f(1);",
+			Undefined,
+			[]string{"1:48: undefined: Opt (undefined)"},
+		},
+		{
+			"let f = fn() { enum E { A }; enum F { B(x) }; match (F.B(1)) { F.B(y) => 0 } }; f();",
+			Unused,
+			[]string{"1:21: enum E is never used (unused)", "1:68: variable y is never used (unused)"},
+		},
+		{
+			"let ch = channel(1); select { v = ch.recv() => v + w, ch.send(x) => 0, _ => 1 }; spawn g();",
+			Undefined,
+			[]string{"1:52: undefined: w (undefined)", "1:63: undefined: x (undefined)", "1:88: undefined: g (undefined)"},
+		},
+		{
+			"let f = fn(a, b) { a + b }; 1 |> f; 2 |> f(3); 4 |> f(5, _, 6);",
+			Arity,
+			[]string{"1:34: f called with 1 arguments, want 2 (arity)", "1:53: f called with 3 arguments, want 2 (arity)"},
+		},
+		{
+			"let xs = [1]; xs |> map(_, g);",
+			Undefined,
+			[]string{"1:28: undefined: g (undefined)"},
+		},
+		{
+			"for (x in xs) { x + y };",
+			Undefined,
+			[]string{"1:11: undefined: xs (undefined)", "1:21: undefined: y (undefined)"},
+		},
+		{
+			"let f = fn(xs) { for ([i, x] in xs) { i } }; f([]);",
+			Unused,
+			[]string{"1:27: variable x is never used (unused)"},
+		},
+		{
+			"let f = fn(ch) { select { v = ch.recv() => 0 } }; f(channel());",
+			Unused,
+			[]string{"1:27: variable v is never used (unused)"},
+		},
+		{
+			"let f = fn(a, b, _c) { let d = 1; a }; f(1, 2, 3);",
+			Unused,
+			[]string{
+				"1:15: parameter b is never used (unused)",
+				"1:28: variable d is never used (unused)",
+			},
+		},
+		{
+			"let x = 1; let f = fn(x) { let len = x; len }; f(x);",
+			Shadow,
+			[]string{
+				"1:23: parameter x shadows an outer declaration (shadow)",
+				"1:32: variable len shadows an outer declaration (shadow)",
+			},
+		},
+		{
+			"let f = fn() { return 1; 2; }; f();",
+			Unreachable,
+			[]string{"1:26: unreachable code after return (unreachable)"},
+		},
+		{
+			"let add = fn(a, b) { a + b }; add(1); add(1, 2); fn(x) { x }(1, 2);",
+			Arity,
+			[]string{
+				"1:31: add called with 1 arguments, want 2 (arity)",
+				"1:50: fn(x) x called with 2 arguments, want 1 (arity)",
+			},
+		},
+		{
+			"let f = fn(a, b = 1) { a + b }; f(); f(1); f(1, 2); f(1, 2, 3); f(...[1, 2, 3]);",
+			Arity,
+			[]string{
+				"1:33: f called with 0 arguments, want 1 to 2 (arity)",
+				"1:53: f called with 3 arguments, want 1 to 2 (arity)",
+			},
+		},
+		{
+			"let f = fn(a, ...r) { a + len(r) }; f(); f(1, 2, 3); f(a: 1);",
+			Arity,
+			[]string{"1:37: f called with 0 arguments, want at least 1 (arity)"},
+		},
+		{
+			"let f = fn(a = b, c = d) { a }; f();",
+			Undefined,
+			[]string{"1:16: undefined: b (undefined)", "1:23: undefined: d (undefined)"},
+		},
+		{
+			"let add = fn(a, b) { a + b }; let add = fn(a) { a }; add(1);",
+			Arity,
+			[]string{},
+		},
+		{
+			"let x = 1; if (true) { 1 }; if (!5) { 2 }; if (1 > 2) { 3 }; if (x) { 4 }",
+			ConstantCondition,
+			[]string{
+				"1:12: if condition is always true (constant-condition)",
+				"1:29: if condition is always false (constant-condition)",
+				"1:44: if condition is always false (constant-condition)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := lint(t, tt.input, tt.rule)
+		expectDiagnostics(t, tt.input, got, tt.expected)
+	}
+}
+
+func TestDisabledRules(t *testing.T) {
+	input := "let f = fn(a) { return y; 1 }; if (true) { f(1, 2) }"
+
+	if got := lint(t, input); len(got) != 5 {
+		t.Fatalf("expected 5 diagnostics with all rules enabled. got=%q", got)
+	}
+
+	cfg := NewConfig()
+	cfg.Disable(Undefined)
+	cfg.Disable(Arity)
+
+	p := parser.New(lexer.New(input))
+	for _, d := range Lint(p.ParseProgram(), cfg) {
+		if d.Rule == Undefined || d.Rule == Arity {
+			t.Errorf("disabled rule reported: %s", d)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	for _, r := range AllRules {
+		got, err := ParseRule(string(r))
+		if err != nil || got != r {
+			t.Errorf("ParseRule(%q) = %q, %v", r, got, err)
+		}
+	}
+
+	if _, err := ParseRule("nope"); err == nil {
+		t.Errorf("expected error for unknown rule")
+	}
+}
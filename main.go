@@ -7,7 +7,47 @@ import (
 	"os/user"
 )
 
+const usage = `usage:
+	monkey                      start the REPL
+	monkey lex [--json] FILE    print the tokens of FILE
+	monkey parse [--json] FILE  print the syntax tree of FILE
+	monkey lint [flags] FILE... report likely mistakes in FILEs
+	monkey check FILE           type check FILE
+	monkey run FILE             evaluate FILE and print its value
+
+FILE may be - to read standard input.
+`
+
 func main() {
+	if len(os.Args) < 2 {
+		startRepl()
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "lex":
+		err = lexCmd(os.Args[2:], os.Stdout)
+	case "parse":
+		err = parseCmd(os.Args[2:], os.Stdout)
+	case "lint":
+		err = lintCmd(os.Args[2:], os.Stdout)
+	case "check":
+		err = checkCmd(os.Args[2:], os.Stdout)
+	case "run":
+		err = runCmd(os.Args[2:], os.Stdout)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func startRepl() {
 	user, err := user.Current()
 
 	if err != nil {
@@ -0,0 +1,254 @@
+package object
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Equal reports whether a and b are structurally equal: scalars by value,
+// arrays element by element, ranges by the integers they hold, hashes by
+// their key/value pairs and structs of the same declaration field by
+// field. Other objects, such as functions, are only equal to themselves.
+// Structs that contain themselves are equal if no field tells them apart.
+// If either of a and b is a struct whose type has an eq method, they are
+// equal if it returns anything but false, null or an error.
+func Equal(a, b Object) bool {
+	return equal(a, b, nil)
+}
+
+// equal is Equal, taking as equal the pairs of structs in seen, which
+// are being compared further up.
+func equal(a, b Object, seen map[[2]*Struct]bool) bool {
+	if eq, args, ok := EqMethod(a, b); ok && Apply != nil {
+		switch result := Apply(eq, args...).(type) {
+		case *Boolean:
+			return result.Value
+		case *Null, *Error:
+			return false
+		}
+		return true
+	}
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if x, y, ok := floats(a, b); ok {
+		return x == y
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value == b.(*Integer).Value
+	case *BigInt:
+		return a.Value.Cmp(b.(*BigInt).Value) == 0
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Null:
+		return true
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Array:
+		b := b.(*Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Range:
+		b := b.(*Range)
+		n := a.Len()
+		return n == b.Len() && (n == 0 || a.Start == b.Start)
+	case *Variant:
+		b := b.(*Variant)
+		if a.Def != b.Def {
+			return false
+		}
+		for i := range a.Values {
+			if !equal(a.Values[i], b.Values[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Struct:
+		b := b.(*Struct)
+		if a.Def != b.Def {
+			return false
+		}
+		pair := [2]*Struct{a, b}
+		if seen[pair] {
+			return true
+		}
+		if seen == nil {
+			seen = map[[2]*Struct]bool{}
+		}
+		seen[pair] = true
+		for _, name := range a.Def.Fields {
+			x, _ := a.Field(name)
+			y, _ := b.Field(name)
+			if !equal(x, y, seen) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		for key, pair := range a.Pairs {
+			other, ok := b.Pairs[key]
+			if !ok || !equal(pair.Value, other.Value, seen) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// EqMethod returns the eq method of a, or else of b, if either is a
+// struct whose type has one, with the arguments to call it with: that
+// struct and then the other object.
+func EqMethod(a, b Object) (Object, []Object, bool) {
+	if s, ok := a.(*Struct); ok {
+		if eq, ok := s.Def.Method("eq"); ok {
+			return eq, []Object{a, b}, true
+		}
+	}
+	if s, ok := b.(*Struct); ok {
+		if eq, ok := s.Def.Method("eq"); ok {
+			return eq, []Object{b, a}, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Compare orders a and b, returning -1, 0 or +1. Numbers compare
+// numerically, strings lexicographically by code point and arrays
+// lexicographically by their elements. Any other combination is an error.
+func Compare(a, b Object) (int, error) {
+	if x, y, ok := floats(a, b); ok {
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		case x == y:
+			return 0, nil
+		}
+		return 0, fmt.Errorf("cannot compare NaN")
+	}
+
+	if x, ok := a.(*Integer); ok {
+		if y, ok := b.(*Integer); ok {
+			switch {
+			case x.Value < y.Value:
+				return -1, nil
+			case x.Value > y.Value:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if x, y, ok := bigInts(a, b); ok {
+		return x.Cmp(y), nil
+	}
+
+	switch a := a.(type) {
+	case *String:
+		if b, ok := b.(*String); ok {
+			return strings.Compare(a.Value, b.Value), nil
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			for i := 0; i < len(a.Elements) && i < len(b.Elements); i++ {
+				c, err := Compare(a.Elements[i], b.Elements[i])
+				if err != nil || c != 0 {
+					return c, err
+				}
+			}
+			switch {
+			case len(a.Elements) < len(b.Elements):
+				return -1, nil
+			case len(a.Elements) > len(b.Elements):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %s with %s", a.Type(), b.Type())
+}
+
+// floats converts a and b to float64 if both are numbers and at least one
+// of them is a Float.
+func floats(a, b Object) (float64, float64, bool) {
+	_, aFloat := a.(*Float)
+	_, bFloat := b.(*Float)
+	if !aFloat && !bFloat {
+		return 0, 0, false
+	}
+
+	x, ok := ToFloat(a)
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := ToFloat(b)
+	return x, y, ok
+}
+
+// bigInts converts a and b to big.Ints if both are integers.
+func bigInts(a, b Object) (*big.Int, *big.Int, bool) {
+	x, ok := ToBig(a)
+	if !ok {
+		return nil, nil, false
+	}
+	y, ok := ToBig(b)
+	return x, y, ok
+}
+
+// ToFloat returns the value of an Integer, BigInt or Float as a float64.
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f, true
+	case *Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+// ToBig returns the value of an Integer or BigInt as a big.Int. The result
+// must not be modified.
+func ToBig(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	case *BigInt:
+		return obj.Value, true
+	}
+	return nil, false
+}
+
+// NewInteger returns n as an Integer if it fits in an int64 and as a
+// BigInt otherwise.
+func NewInteger(n *big.Int) Object {
+	if n.IsInt64() {
+		return &Integer{Value: n.Int64()}
+	}
+	return &BigInt{Value: n}
+}
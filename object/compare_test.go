@@ -0,0 +1,120 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestStringHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff1 := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if hello1.HashKey() == diff1.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	one := &Integer{Value: 1}
+	str := &String{Value: "a"}
+	fn := &Function{}
+	point := &StructDef{Name: "Point", Fields: []string{"x"}}
+	other := &StructDef{Name: "Point", Fields: []string{"x"}}
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{one, &Integer{Value: 1}, true},
+		{one, &Integer{Value: 2}, false},
+		{str, &String{Value: "a"}, true},
+		{one, str, false},
+		{&Null{}, &Null{}, true},
+		{&Array{Elements: []Object{one, str}}, &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, true},
+		{&Array{Elements: []Object{one}}, &Array{Elements: []Object{}}, false},
+		{
+			&Hash{Pairs: map[HashKey]HashPair{str.HashKey(): {Key: str, Value: one}}},
+			&Hash{Pairs: map[HashKey]HashPair{str.HashKey(): {Key: str, Value: &Integer{Value: 1}}}},
+			true,
+		},
+		{one, &Float{Value: 1}, true},
+		{&Float{Value: 0.5}, &Float{Value: 0.5}, true},
+		{&Float{Value: 0.5}, one, false},
+		{&Struct{Def: point, Fields: map[string]Object{"x": one}}, &Struct{Def: point, Fields: map[string]Object{"x": &Integer{Value: 1}}}, true},
+		{&Struct{Def: point, Fields: map[string]Object{"x": one}}, &Struct{Def: point, Fields: map[string]Object{"x": str}}, false},
+		{&Struct{Def: point, Fields: map[string]Object{"x": one}}, &Struct{Def: other, Fields: map[string]Object{"x": one}}, false},
+		{&Range{Start: 1, End: 3}, &Range{Start: 1, End: 2, Inclusive: true}, true},
+		{&Range{Start: 1, End: 3}, &Range{Start: 0, End: 2}, false},
+		{&Range{Start: 5, End: 1}, &Range{Start: 0, End: 0}, true},
+		{&Range{Start: 1, End: 3}, &Array{Elements: []Object{one, &Integer{Value: 2}}}, false},
+		{fn, fn, true},
+		{fn, &Function{}, false},
+	}
+
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("tests[%d] Equal(%s, %s) = %t, want %t", i, tt.a.Inspect(), tt.b.Inspect(), got, tt.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	arr := func(elems ...Object) *Array { return &Array{Elements: elems} }
+	i := func(v int64) *Integer { return &Integer{Value: v} }
+	s := func(v string) *String { return &String{Value: v} }
+
+	tests := []struct {
+		a, b     Object
+		expected int
+	}{
+		{i(1), i(2), -1},
+		{i(2), i(2), 0},
+		{s("b"), s("a"), 1},
+		{s("a"), s("ab"), -1},
+		{arr(i(1), i(2)), arr(i(1), i(3)), -1},
+		{arr(i(1)), arr(i(1)), 0},
+		{arr(i(1), i(0)), arr(i(1)), 1},
+		{i(1), &Float{Value: 1.5}, -1},
+		{&BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, i(1), 1},
+		{i(-1), &BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, -1},
+		{&Float{Value: 2}, i(2), 0},
+	}
+
+	for n, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("tests[%d] unexpected error: %s", n, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("tests[%d] Compare(%s, %s) = %d, want %d", n, tt.a.Inspect(), tt.b.Inspect(), got, tt.expected)
+		}
+	}
+
+	if _, err := Compare(i(1), s("1")); err == nil {
+		t.Errorf("expected error comparing INTEGER with STRING")
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{2, "2.0"},
+		{-0.25, "-0.25"},
+		{1e6, "1000000.0"},
+		{1e21, "1e+21"},
+	}
+
+	for _, tt := range tests {
+		if got := (&Float{Value: tt.value}).Inspect(); got != tt.expected {
+			t.Errorf("Inspect() of %v wrong. want=%q, got=%q", tt.value, tt.expected, got)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package object
 
+import "sync"
+
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 	return &Environment{store: s, outer: nil}
@@ -7,17 +9,112 @@ func NewEnvironment() *Environment {
 
 func NewEnclosedEnv(outer *Environment) *Environment {
 	env := NewEnvironment()
-	env.outer = env
+	env.outer = outer
+	return env
+}
+
+// NewFrameEnv returns an environment enclosed by outer with room for the
+// size local variables the resolver assigned to a function body.
+func NewFrameEnv(outer *Environment, size int) *Environment {
+	env := NewEnclosedEnv(outer)
+	env.slots = make([]Object, size)
 	return env
 }
 
+// Environment holds the variables of one scope. Tasks started by spawn
+// share the environments of the functions they close over, so every
+// access is guarded by mu, except to a frozen environment, which no
+// longer changes.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	mu     sync.RWMutex
+	store  map[string]Object
+	slots  []Object
+	outer  *Environment
+	frozen bool
+}
+
+// Freeze makes e read-only, so that programs evaluated concurrently can
+// share it as their base environment, each in an environment of its own
+// enclosed by e. Lookups in e then no longer lock it. Freeze must be
+// called before e is shared; Set and SetAt panic afterwards.
+//
+// Freeze also freezes what programs could otherwise change through e:
+// the environments enclosing e and the closures in it, and the structs
+// and struct declarations reachable from their variables, whose
+// SetField and SetMethod then fail.
+func (e *Environment) Freeze() {
+	e.mu.Lock()
+	if e.frozen {
+		e.mu.Unlock()
+		return
+	}
+	e.frozen = true
+	e.mu.Unlock()
+
+	for _, obj := range e.store {
+		freeze(obj)
+	}
+	for _, obj := range e.slots {
+		freeze(obj)
+	}
+	if e.outer != nil {
+		e.outer.Freeze()
+	}
+}
+
+// freeze freezes obj and the objects it holds.
+func freeze(obj Object) {
+	switch obj := obj.(type) {
+	case *Function:
+		if obj.Env != nil {
+			obj.Env.Freeze()
+		}
+	case *BoundMethod:
+		freeze(obj.Receiver)
+		freeze(obj.Method)
+	case *StructDef:
+		obj.mu.Lock()
+		obj.frozen = true
+		obj.mu.Unlock()
+	case *Struct:
+		obj.mu.Lock()
+		if obj.frozen {
+			obj.mu.Unlock()
+			return
+		}
+		obj.frozen = true
+		obj.mu.Unlock()
+
+		freeze(obj.Def)
+		for _, val := range obj.Fields {
+			freeze(val)
+		}
+	case *Array:
+		for _, el := range obj.Elements {
+			freeze(el)
+		}
+	case *Hash:
+		for _, pair := range obj.Pairs {
+			freeze(pair.Value)
+		}
+	case *Variant:
+		for _, val := range obj.Values {
+			freeze(val)
+		}
+	}
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	var obj Object
+	var ok bool
+	if e.frozen {
+		obj, ok = e.store[name]
+	} else {
+		e.mu.RLock()
+		obj, ok = e.store[name]
+		e.mu.RUnlock()
+	}
+
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
@@ -25,6 +122,42 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	if e.frozen {
+		panic("object: Set on a frozen environment")
+	}
+	e.mu.Lock()
 	e.store[name] = val
+	e.mu.Unlock()
+	return val
+}
+
+// GetAt returns the local in slot of the environment depth levels up the
+// chain. It reports false if that slot has not been assigned yet.
+func (e *Environment) GetAt(depth, slot int) (Object, bool) {
+	for i := 0; i < depth && e != nil; i++ {
+		e = e.outer
+	}
+	if e == nil {
+		return nil, false
+	}
+
+	if !e.frozen {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+	}
+	if slot >= len(e.slots) || e.slots[slot] == nil {
+		return nil, false
+	}
+	return e.slots[slot], true
+}
+
+// SetAt assigns a local of this environment.
+func (e *Environment) SetAt(slot int, val Object) Object {
+	if e.frozen {
+		panic("object: SetAt on a frozen environment")
+	}
+	e.mu.Lock()
+	e.slots[slot] = val
+	e.mu.Unlock()
 	return val
 }
@@ -0,0 +1,91 @@
+package object
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestFrozenEnvironment(t *testing.T) {
+	base := NewEnvironment()
+	base.Set("x", &Integer{Value: 1})
+	base.Freeze()
+
+	env := NewEnclosedEnv(base)
+	env.Set("y", &Integer{Value: 2})
+	for _, name := range []string{"x", "y"} {
+		if _, ok := env.Get(name); !ok {
+			t.Errorf("%s not found through the enclosing environment", name)
+		}
+	}
+	if _, ok := base.Get("y"); ok {
+		t.Errorf("y leaked into the frozen environment")
+	}
+
+	expectPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s on a frozen environment did not panic", name)
+			}
+		}()
+		f()
+	}
+	expectPanic("Set", func() { base.Set("z", &Integer{Value: 3}) })
+
+	frame := NewFrameEnv(nil, 1)
+	frame.Freeze()
+	expectPanic("SetAt", func() { frame.SetAt(0, &Integer{Value: 3}) })
+}
+
+func TestFreezeReachableStructs(t *testing.T) {
+	def := &StructDef{Name: "P", Fields: []string{"x"}}
+	inner := &Struct{Def: def, Fields: map[string]Object{"x": &Integer{Value: 1}}}
+	outer := &Struct{Def: def, Fields: map[string]Object{"x": &Array{Elements: []Object{inner}}}}
+
+	base := NewEnvironment()
+	base.Set("p", outer)
+	base.Freeze()
+
+	if inner.SetField("x", &Integer{Value: 2}) || outer.SetField("x", &Integer{Value: 2}) {
+		t.Errorf("SetField succeeded on a struct reachable from a frozen environment")
+	}
+	if def.SetMethod("m", &Null{}) {
+		t.Errorf("SetMethod succeeded on a struct declaration reachable from a frozen environment")
+	}
+
+	fresh := &Struct{Def: def, Fields: map[string]Object{"x": &Integer{Value: 1}}}
+	if !fresh.SetField("x", &Integer{Value: 2}) {
+		t.Errorf("SetField failed on a struct created after Freeze")
+	}
+}
+
+func TestEnvironmentConcurrentAccess(t *testing.T) {
+	base := NewEnvironment()
+	base.Set("shared", &Integer{Value: 0})
+	base.Freeze()
+
+	env := NewEnclosedEnv(base)
+	frame := NewFrameEnv(env, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := "v" + strconv.Itoa(i)
+			for j := 0; j < 100; j++ {
+				env.Set(name, &Integer{Value: int64(j)})
+				frame.SetAt(0, &Integer{Value: int64(j)})
+				if _, ok := frame.Get(name); !ok {
+					t.Errorf("%s not found", name)
+				}
+				if _, ok := frame.Get("shared"); !ok {
+					t.Errorf("shared not found")
+				}
+				frame.GetAt(0, 0)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,24 @@
+package object
+
+// Iterator produces a sequence of values one at a time, such as the
+// values yielded by a generator. It can be iterated over only once.
+type Iterator struct {
+	// Next returns the next value, nil at the end, or an *Error if
+	// producing the value failed. After nil or an error it keeps
+	// returning nil.
+	Next func() Object
+
+	// Stop releases whatever the iterator holds when it will not be
+	// read to the end. It may be nil.
+	Stop func()
+}
+
+func (it *Iterator) Type() ObjectType { return ITERATOR_OBJ }
+func (it *Iterator) Inspect() string  { return "<iterator>" }
+
+// Close calls Stop, if there is one.
+func (it *Iterator) Close() {
+	if it.Stop != nil {
+		it.Stop()
+	}
+}
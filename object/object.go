@@ -3,8 +3,14 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
 	"monkey/ast"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type ObjectType string
@@ -19,6 +25,22 @@ const (
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
+	QUOTE_OBJ        = "QUOTE"
+	MACRO_OBJ        = "MACRO"
+	HASH_OBJ         = "HASH"
+	FLOAT_OBJ        = "FLOAT"
+	MODULE_OBJ       = "MODULE"
+	BIGINT_OBJ       = "BIGINT"
+	STRUCT_DEF_OBJ   = "STRUCT_DEF"
+	STRUCT_OBJ       = "STRUCT"
+	METHOD_OBJ       = "METHOD"
+	ENUM_OBJ         = "ENUM"
+	VARIANT_DEF_OBJ  = "VARIANT_DEF"
+	VARIANT_OBJ      = "VARIANT"
+	TASK_OBJ         = "TASK"
+	CHANNEL_OBJ      = "CHANNEL"
+	ITERATOR_OBJ     = "ITERATOR"
+	RANGE_OBJ        = "RANGE"
 )
 
 type Object interface {
@@ -33,6 +55,35 @@ type Integer struct {
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+// BigInt is an integer outside the range of int64. Arithmetic produces
+// one only when a result overflows Integer and turns results that fit
+// back into Integers, so the two never hold the same value.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Inspect() string  { return b.Value.String() }
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+
+type Float struct {
+	Value float64
+}
+
+// Inspect always shows a fraction or an exponent, so that 2.0 does not
+// look like the integer 2.
+func (f *Float) Inspect() string {
+	if math.IsInf(f.Value, 0) || math.IsNaN(f.Value) || math.Abs(f.Value) >= 1e21 {
+		return strconv.FormatFloat(f.Value, 'g', -1, 64)
+	}
+
+	s := strconv.FormatFloat(f.Value, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
 type Boolean struct {
 	Value bool
 }
@@ -52,17 +103,32 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Error is a runtime error. Line and Column locate the expression that
+// failed, if known.
 type Error struct {
 	Message string
+	Line    int
+	Column  int
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string {
+	if e.Line == 0 {
+		return "ERROR: " + e.Message
+	}
+	return fmt.Sprintf("ERROR: %d:%d: %s", e.Line, e.Column, e.Message)
+}
 
+// Function is a closure over Env. Defaults, Variadic and Generator are
+// as in ast.FunctionLiteral.
 type Function struct {
-	Params []*ast.Identifier
-	Body   *ast.BlockStatement
-	Env    *Environment
+	Params    []*ast.Identifier
+	Defaults  []ast.Expression
+	Variadic  bool
+	Body      *ast.BlockStatement
+	Env       *Environment
+	Slots     int
+	Generator bool
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -70,8 +136,15 @@ func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Params {
-		params = append(params, p.String())
+	for i, p := range f.Params {
+		param := p.String()
+		if f.Variadic && i == len(f.Params)-1 {
+			param = "..." + param
+		}
+		if i < len(f.Defaults) && f.Defaults[i] != nil {
+			param += " = " + f.Defaults[i].String()
+		}
+		params = append(params, param)
 	}
 
 	out.WriteString("fn")
@@ -104,12 +177,14 @@ type Array struct {
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
-func (ao *Array) Inspect() string {
+func (ao *Array) Inspect() string  { return ao.inspect(nil) }
+
+func (ao *Array) inspect(seen map[*Struct]bool) string {
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspect(e, seen))
 	}
 
 	out.WriteString("[")
@@ -118,3 +193,322 @@ func (ao *Array) Inspect() string {
 
 	return out.String()
 }
+
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() ObjectType { return QUOTE_OBJ }
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.String() + ")"
+}
+
+type Macro struct {
+	Params []*ast.Identifier
+	Body   *ast.BlockStatement
+	Env    *Environment
+}
+
+func (m *Macro) Type() ObjectType { return MACRO_OBJ }
+func (m *Macro) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range m.Params {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable is implemented by the objects that can be used as hash keys.
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(b.Value.String()))
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+
+	if b.Value {
+		value = 1
+	} else {
+		value = 0
+	}
+
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string  { return h.inspect(nil) }
+
+func (h *Hash) inspect(seen map[*Struct]bool) string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), inspect(pair.Value, seen)))
+	}
+	sort.Strings(pairs)
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Module is a namespace of named values, such as the math library, whose
+// members are accessed as module.name.
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "<module " + m.Name + ">" }
+
+// StructDef is a struct declaration. Calling it constructs a Struct.
+// Methods holds the functions added to it by impl blocks; once the
+// definition is in use, access them through Method and SetMethod.
+type StructDef struct {
+	Name    string
+	Fields  []string
+	Methods map[string]Object
+
+	mu     sync.RWMutex
+	frozen bool
+}
+
+func (sd *StructDef) Type() ObjectType { return STRUCT_DEF_OBJ }
+func (sd *StructDef) Inspect() string {
+	return "struct " + sd.Name + " { " + strings.Join(sd.Fields, ", ") + " }"
+}
+
+// Method returns the method of sd called name.
+func (sd *StructDef) Method(name string) (Object, bool) {
+	sd.mu.RLock()
+	defer sd.mu.RUnlock()
+	m, ok := sd.Methods[name]
+	return m, ok
+}
+
+// SetMethod adds the method name to sd, replacing any of that name. It
+// reports false, leaving sd alone, if sd belongs to a frozen environment.
+func (sd *StructDef) SetMethod(name string, m Object) bool {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if sd.frozen {
+		return false
+	}
+	if sd.Methods == nil {
+		sd.Methods = map[string]Object{}
+	}
+	sd.Methods[name] = m
+	return true
+}
+
+// Struct is an instance of Def. Its Fields can be assigned to, and every
+// reference to the instance sees the change. Tasks may share an instance,
+// so once it has been constructed its fields are accessed through Field
+// and SetField.
+type Struct struct {
+	Def    *StructDef
+	Fields map[string]Object
+
+	mu     sync.RWMutex
+	frozen bool
+}
+
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+
+// Field returns the value of the field of s called name.
+func (s *Struct) Field(name string) (Object, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.Fields[name]
+	return val, ok
+}
+
+// SetField assigns the field of s called name. It reports false, leaving
+// s alone, if s belongs to a frozen environment.
+func (s *Struct) SetField(name string, val Object) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozen {
+		return false
+	}
+	s.Fields[name] = val
+	return true
+}
+
+// Inspect shows the string the to_string method of the type of s
+// returns, or else the fields of s. A struct that contains itself shows
+// as Name{...} where it recurs.
+func (s *Struct) Inspect() string { return s.inspect(nil) }
+
+func (s *Struct) inspect(seen map[*Struct]bool) string {
+	if seen[s] {
+		return s.Def.Name + "{...}"
+	}
+	if seen == nil {
+		seen = map[*Struct]bool{}
+	}
+	seen[s] = true
+	defer delete(seen, s)
+
+	if m, ok := s.Def.Method("to_string"); ok && Apply != nil {
+		if str, ok := Apply(m, s).(*String); ok {
+			return str.Value
+		}
+	}
+
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, name := range s.Def.Fields {
+		val, _ := s.Field(name)
+		fields = append(fields, name+": "+inspect(val, seen))
+	}
+
+	out.WriteString(s.Def.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// BoundMethod is a method together with the instance it was looked up on,
+// which it receives as its first argument when called.
+type BoundMethod struct {
+	Name     string
+	Receiver *Struct
+	Method   Object
+}
+
+func (bm *BoundMethod) Type() ObjectType { return METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string {
+	return "method " + bm.Receiver.Def.Name + "." + bm.Name
+}
+
+// inspect returns obj.Inspect(), passing seen, the structs being
+// inspected further up, down to the objects that may contain them.
+func inspect(obj Object, seen map[*Struct]bool) string {
+	if c, ok := obj.(interface {
+		inspect(seen map[*Struct]bool) string
+	}); ok {
+		return c.inspect(seen)
+	}
+	return obj.Inspect()
+}
+
+// Apply calls the function fn with args. The evaluator sets it so that
+// objects can run user-defined methods, such as to_string in Inspect.
+var Apply func(fn Object, args ...Object) Object
+
+// Enum is an enum declaration. Its members are its variants.
+type Enum struct {
+	Name     string
+	Variants []*VariantDef
+}
+
+func (e *Enum) Type() ObjectType { return ENUM_OBJ }
+func (e *Enum) Inspect() string {
+	var out bytes.Buffer
+
+	variants := []string{}
+	for _, v := range e.Variants {
+		if len(v.Fields) == 0 {
+			variants = append(variants, v.Name)
+			continue
+		}
+		variants = append(variants, v.Name+"("+strings.Join(v.Fields, ", ")+")")
+	}
+
+	out.WriteString("enum " + e.Name + " { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// Variant returns the variant of e called name, or nil if there is none.
+func (e *Enum) Variant(name string) *VariantDef {
+	for _, v := range e.Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// VariantDef is one variant of an enum. Calling it constructs a Variant
+// holding one value per field.
+type VariantDef struct {
+	Enum   *Enum
+	Name   string
+	Fields []string
+}
+
+func (vd *VariantDef) Type() ObjectType { return VARIANT_DEF_OBJ }
+func (vd *VariantDef) Inspect() string  { return vd.Enum.Name + "." + vd.Name }
+
+// Variant is a value of an enum: one of its variants together with the
+// values of that variant's fields.
+type Variant struct {
+	Def    *VariantDef
+	Values []Object
+}
+
+func (v *Variant) Type() ObjectType { return VARIANT_OBJ }
+func (v *Variant) Inspect() string  { return v.inspect(nil) }
+
+func (v *Variant) inspect(seen map[*Struct]bool) string {
+	if len(v.Values) == 0 {
+		return v.Def.Inspect()
+	}
+
+	values := []string{}
+	for _, val := range v.Values {
+		values = append(values, inspect(val, seen))
+	}
+	return v.Def.Inspect() + "(" + strings.Join(values, ", ") + ")"
+}
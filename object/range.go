@@ -0,0 +1,48 @@
+package object
+
+import "strconv"
+
+// Range is the integers from Start up to End, including End if Inclusive.
+// Its elements are computed when asked for rather than stored. A range
+// whose End is not past its Start is empty.
+type Range struct {
+	Start     int64
+	End       int64
+	Inclusive bool
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	op := ".."
+	if r.Inclusive {
+		op = "..="
+	}
+	return strconv.FormatInt(r.Start, 10) + op + strconv.FormatInt(r.End, 10)
+}
+
+// Len returns the number of integers in r, which must fit in an int64.
+func (r *Range) Len() int64 {
+	if r.End < r.Start || (r.End == r.Start && !r.Inclusive) {
+		return 0
+	}
+	n := r.End - r.Start
+	if r.Inclusive {
+		n++
+	}
+	return n
+}
+
+// At returns the element at index i, which must be less than Len.
+func (r *Range) At(i int64) *Integer {
+	return &Integer{Value: r.Start + i}
+}
+
+// Elements returns the integers in r as a slice.
+func (r *Range) Elements() []Object {
+	n := r.Len()
+	elems := make([]Object, n)
+	for i := int64(0); i < n; i++ {
+		elems[i] = r.At(i)
+	}
+	return elems
+}
@@ -0,0 +1,85 @@
+package object
+
+import "sync"
+
+// Task is a function call running in a goroutine of its own, started by
+// spawn. Its result is available once it finishes.
+type Task struct {
+	done   chan struct{}
+	result Object
+}
+
+func NewTask() *Task {
+	return &Task{done: make(chan struct{})}
+}
+
+func (t *Task) Type() ObjectType { return TASK_OBJ }
+func (t *Task) Inspect() string  { return "<task>" }
+
+// Finish records the result of the task and wakes everyone waiting for
+// it. It must be called exactly once.
+func (t *Task) Finish(result Object) {
+	t.result = result
+	close(t.done)
+}
+
+// Wait blocks until the task has finished and returns its result.
+func (t *Task) Wait() Object {
+	<-t.done
+	return t.result
+}
+
+// Channel passes values between tasks. A Channel created with a size
+// greater than zero buffers that many values; otherwise each send waits
+// for a receive.
+type Channel struct {
+	C chan Object
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewChannel(size int) *Channel {
+	return &Channel{C: make(chan Object, size)}
+}
+
+func (c *Channel) Type() ObjectType { return CHANNEL_OBJ }
+func (c *Channel) Inspect() string  { return "<channel>" }
+
+// Send sends val on c, waiting until there is room for it. It reports
+// false if c is or becomes closed.
+func (c *Channel) Send(val Object) (ok bool) {
+	// A send blocked when the channel is closed panics; that is the
+	// same failure as sending on a channel already closed.
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	if c.Closed() {
+		return false
+	}
+	c.C <- val
+	return true
+}
+
+// Close closes c. It reports false if c was already closed.
+func (c *Channel) Close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	close(c.C)
+	return true
+}
+
+// Closed reports whether c has been closed.
+func (c *Channel) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (p *Parser) parseYieldExpr() ast.Expression {
+	exp := &ast.YieldExpr{Token: p.curToken}
+
+	if !p.inFunction {
+		p.errors = append(p.errors, "yield outside a function")
+	}
+	p.yielded = true
+
+	p.nextToken()
+	exp.Value = p.parseExpression(LOWEST)
+	if exp.Value == nil {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Target = p.parsePattern()
+	if stmt.Target == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatment()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		exp.Arms = append(exp.Arms, arm)
+
+		// Arms are separated by commas, which may be left out after
+		// a block.
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		} else if !p.curTokenIs(token.RBRACE) {
+			break
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: p.curToken}
+
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	arm.Body = p.parseArmBody()
+	if arm.Body == nil {
+		return nil
+	}
+
+	return arm
+}
+
+// parseArmBody parses => followed by a block or a single expression,
+// which it wraps in a block of its own.
+func (p *Parser) parseArmBody() *ast.BlockStatement {
+	if !p.expectPeek(token.FAT_ARROW) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		return p.parseBlockStatment()
+	}
+
+	p.nextToken()
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(LOWEST)
+	return &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		if p.peekTokenIs(token.DOT) {
+			return p.parseVariantPattern()
+		}
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		return &ast.BindingPattern{Token: p.curToken, Name: ident}
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		return &ast.LiteralPattern{Token: p.curToken, Value: p.prefixParseFns[p.curToken.Type]()}
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			break
+		}
+		return &ast.LiteralPattern{Token: p.curToken, Value: p.parsePrefixExpression()}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+
+	msg := fmt.Sprintf("expected a pattern, got %s", p.curToken.Type)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			pattern.Rest = p.parsePattern()
+			switch pattern.Rest.(type) {
+			case *ast.BindingPattern, *ast.WildcardPattern:
+			case nil:
+				return nil
+			default:
+				msg := fmt.Sprintf("expected a name or _ after ..., got %s", pattern.Rest)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+			break
+		}
+
+		el := p.parseElementPattern()
+		if el == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, el)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseElementPattern parses an element of an array or hash pattern,
+// which unlike a whole pattern may be a name with a default: name = value.
+func (p *Parser) parseElementPattern() ast.Pattern {
+	pattern := p.parsePattern()
+
+	binding, ok := pattern.(*ast.BindingPattern)
+	if ok && p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		binding.Default = p.parseExpression(LOWEST)
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		var value ast.Pattern
+		switch p.curToken.Type {
+		case token.IDENT:
+			// A name is short for the string key of the same name,
+			// and on its own also binds the value to that name.
+			key := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+			pattern.Keys = append(pattern.Keys, key)
+			if !p.peekTokenIs(token.COLON) {
+				value = p.parseElementPattern()
+			}
+		case token.STRING, token.INT, token.TRUE, token.FALSE:
+			pattern.Keys = append(pattern.Keys, p.prefixParseFns[p.curToken.Type]())
+		default:
+			msg := fmt.Sprintf("expected a literal hash key, got %s", p.curToken.Type)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		if value == nil {
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			p.nextToken()
+			value = p.parseElementPattern()
+		}
+		if value == nil {
+			return nil
+		}
+		pattern.Values = append(pattern.Values, value)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"monkey/ast"
 	"monkey/lexer"
 	"monkey/token"
@@ -15,6 +17,11 @@ type Parser struct {
 	errors         []string
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// inFunction and yielded track the function literal whose body is
+	// being parsed, to find generators and misplaced yields.
+	inFunction bool
+	yielded    bool
 }
 
 const (
@@ -22,6 +29,9 @@ const (
 	LOWEST
 	EQUALS
 	LESSGREATER
+	PIPE
+	RANGE
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
@@ -30,16 +40,23 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.EQ:        EQUALS,
+	token.NOT_EQ:    EQUALS,
+	token.LT:        LESSGREATER,
+	token.GT:        LESSGREATER,
+	token.PIPE:      PIPE,
+	token.DOTDOT:    RANGE,
+	token.DOTDOT_EQ: RANGE,
+	token.SHL:       SHIFT,
+	token.SHR:       SHIFT,
+	token.PLUS:      SUM,
+	token.MINUS:     SUM,
+	token.SLASH:     PRODUCT,
+	token.ASTERISK:  PRODUCT,
+	token.PERCENT:   PRODUCT,
+	token.LPAREN:    CALL,
+	token.LBRACKET:  INDEX,
+	token.DOT:       INDEX,
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -51,6 +68,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -60,17 +78,30 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FUNCTION, p.parseFuntionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArray)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.SPAWN, p.parseSpawnExpr)
+	p.registerPrefix(token.SELECT, p.parseSelectExpression)
+	p.registerPrefix(token.YIELD, p.parseYieldExpr)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.SHL, p.parseInfixExpression)
+	p.registerInfix(token.SHR, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpr)
+	p.registerInfix(token.DOTDOT, p.parseRangeExpr)
+	p.registerInfix(token.DOTDOT_EQ, p.parseRangeExpr)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpr)
 	p.registerInfix(token.LBRACKET, p.parseIndex)
 
 	return p
@@ -104,19 +135,40 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatment()
+	case token.STRUCT, token.TYPE:
+		return p.parseStructStatement()
+	case token.IMPL:
+		return p.parseImplStatement()
+	case token.ENUM:
+		return p.parseEnumStatement()
+	case token.FOR:
+		return p.parseForStatement()
 	default:
-		return p.parseExpressionStatement()
+		stmt := p.parseExpressionStatement()
+		if p.peekTokenIs(token.ASSIGN) {
+			return p.parseAssignStatement(stmt.Expression)
+		}
+		return stmt
 	}
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		stmt.Pattern = p.parsePattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Name.Type = p.parseOptionalAnnotation()
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -183,6 +235,12 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if n, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			lit.Big = n
+			return lit
+		}
+	}
 
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as int", p.curToken.Literal)
@@ -194,6 +252,31 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseMemberExpr(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpr{Token: p.curToken, Object: object}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -221,6 +304,19 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseRangeExpr(start ast.Expression) ast.Expression {
+	exp := &ast.RangeExpr{
+		Token:     p.curToken,
+		Start:     start,
+		Inclusive: p.curTokenIs(token.DOTDOT_EQ),
+	}
+
+	p.nextToken()
+	exp.End = p.parseExpression(RANGE)
+
+	return exp
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -292,51 +388,178 @@ func (p *Parser) parseFuntionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.Defaults, lit.Variadic = p.parseFunctionParameters()
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		p.nextToken()
+		lit.ReturnType = p.parseType()
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
+	outerIn, outerYielded := p.inFunction, p.yielded
+	p.inFunction, p.yielded = true, false
 	lit.Body = p.parseBlockStatment()
+	lit.Generator = p.yielded
+	p.inFunction, p.yielded = outerIn, outerYielded
+	markTailCalls(lit)
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{Token: p.curToken}
 
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return identifiers
+	if !p.expectPeek(token.LPAREN) {
+		return nil
 	}
 
-	p.nextToken()
+	params, defaults, variadic := p.parseFunctionParameters()
+	if defaults != nil || variadic {
+		p.errors = append(p.errors, "macro parameters cannot have defaults or be variadic")
+	}
+	lit.Parameters = params
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
 
-	for p.peekTokenIs(token.COMMA) {
+	lit.Body = p.parseBlockStatment()
+
+	return lit
+}
+
+// parseFunctionParameters parses name, name: type, name = default and a
+// final ...name. Once one parameter has a default, the ones after it need
+// one too.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.Expression, bool) {
+	identifiers := []*ast.Identifier{}
+	var defaults []ast.Expression
+	variadic := false
+
+	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
+		return identifiers, nil, false
+	}
+
+	for {
 		p.nextToken()
 
+		if variadic {
+			p.errors = append(p.errors, "rest parameter must be the last parameter")
+			return nil, nil, false
+		}
+		if p.curTokenIs(token.ELLIPSIS) {
+			variadic = true
+			p.nextToken()
+		}
+		if !p.curTokenIs(token.IDENT) {
+			msg := fmt.Sprintf("expected a parameter name, got %s", p.curToken.Type)
+			p.errors = append(p.errors, msg)
+			return nil, nil, false
+		}
+
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		ident.Type = p.parseOptionalAnnotation()
 		identifiers = append(identifiers, ident)
+
+		var def ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			if variadic {
+				msg := fmt.Sprintf("rest parameter %s cannot have a default", ident.Value)
+				p.errors = append(p.errors, msg)
+				return nil, nil, false
+			}
+			p.nextToken()
+			p.nextToken()
+			def = p.parseExpression(LOWEST)
+			if defaults == nil {
+				defaults = make([]ast.Expression, len(identifiers)-1)
+			}
+		} else if defaults != nil && !variadic {
+			msg := fmt.Sprintf("parameter %s needs a default after parameters with defaults", ident.Value)
+			p.errors = append(p.errors, msg)
+			return nil, nil, false
+		}
+		if defaults != nil {
+			defaults = append(defaults, def)
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return nil, nil, false
 	}
 
-	return identifiers
+	return identifiers, defaults, variadic
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExprList(token.RPAREN)
+	exp.Arguments = p.parseCallArguments()
 	return exp
 }
 
+// parseCallArguments parses expressions, ...spread arrays and name: value
+// pairs, which have to come last and may not repeat a name.
+func (p *Parser) parseCallArguments() []ast.Expression {
+	args := []ast.Expression{}
+	named := map[string]bool{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+
+	for {
+		p.nextToken()
+
+		var arg ast.Expression
+		switch {
+		case p.curTokenIs(token.ELLIPSIS):
+			spread := &ast.SpreadExpr{Token: p.curToken}
+			p.nextToken()
+			spread.Value = p.parseExpression(LOWEST)
+			arg = spread
+		case p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON):
+			name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if named[name.Value] {
+				msg := fmt.Sprintf("argument %s given more than once", name.Value)
+				p.errors = append(p.errors, msg)
+			}
+			named[name.Value] = true
+			p.nextToken()
+			p.nextToken()
+			arg = &ast.NamedArgument{Token: name.Token, Name: name, Value: p.parseExpression(LOWEST)}
+		default:
+			arg = p.parseExpression(LOWEST)
+		}
+
+		if _, ok := arg.(*ast.NamedArgument); !ok && len(named) > 0 {
+			p.errors = append(p.errors, "positional argument after named argument")
+		}
+		args = append(args, arg)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return args
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
@@ -347,6 +570,37 @@ func (p *Parser) parseArray() ast.Expression {
 	return array
 }
 
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Keys = []ast.Expression{}
+	hash.Values = []ast.Expression{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
 func (p *Parser) parseExprList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -372,10 +626,27 @@ func (p *Parser) parseExprList(end token.TokenType) []ast.Expression {
 }
 
 func (p *Parser) parseIndex(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpr{Token: p.curToken, Left: left}
+	tok := p.curToken
+
+	var start ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		start = p.parseExpression(LOWEST)
+	}
+
+	if !p.peekTokenIs(token.COLON) {
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return &ast.IndexExpr{Token: tok, Left: left, Index: start}
+	}
 
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	exp := &ast.SliceExpr{Token: tok, Left: left, Start: start}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -401,8 +672,11 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
+// Errors returns the lexical errors reported by the lexer followed by the
+// syntax errors found while parsing.
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := append([]string{}, p.l.Errors()...)
+	return append(errors, p.errors...)
 }
 
 func (p *Parser) peekError(t token.TokenType) {
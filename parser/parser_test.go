@@ -41,6 +41,41 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestDestructuringLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = pair;", "let [a, b] = pair;"},
+		{"let [head, ...tail] = f(xs);", "let [head, ...tail] = f(xs);"},
+		{"let [a, [b, _], c = a + 1] = xs", "let [a, [b, _], c = (a + 1)] = xs;"},
+		{"let {name, age = 0} = person;", "let {name, age = 0} = person;"},
+		{`let {address: {city}, "zip code": zip, 1: one} = h;`, "let {address: {city}, zip code: zip, 1: one} = h;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Name != nil || stmt.Pattern == nil {
+			t.Errorf("stmt.Pattern not set for %q", tt.input)
+		}
+		if got := stmt.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -349,6 +384,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a << b + 1 < c % 2 >> 1",
+			"((a << (b + 1)) < ((c % 2) >> 1))",
+		},
+		{
+			"-math.PI * 2.5",
+			"((-(math.PI)) * 2.5)",
+		},
+		{
+			"math.max(a, b).c[0]",
+			"(((math.max)(a, b).c)[0])",
+		},
 	}
 
 	for _, tt := range tests {
@@ -586,6 +633,59 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestParameterAndArgumentParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a, b = 2) { a }", "fn(a, b = 2) a"},
+		{"fn(a: int = 1, ...rest: [int]) { a }", "fn(a: int = 1, ...rest: [int]) a"},
+		{"fn(...xs) { xs }", "fn(...xs) xs"},
+		{"f(...xs)", "f(...xs)"},
+		{"f(1, ...[2, 3], 4)", "f(1, ...[2, 3], 4)"},
+		{"f(1, b: 2 + 3, c: x)", "f(1, b: (2 + 3), c: x)"},
+		{"f({a: 1})", "f({a: 1})"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestParameterAndArgumentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(...a, b) { a }", "rest parameter must be the last parameter"},
+		{"fn(...a = 1) { a }", "rest parameter a cannot have a default"},
+		{"fn(a = 1, b) { a }", "parameter b needs a default after parameters with defaults"},
+		{"fn(1) { 1 }", "expected a parameter name, got INT"},
+		{"macro(a = 1) { a }", "macro parameters cannot have defaults or be variadic"},
+		{"f(a: 1, 2)", "positional argument after named argument"},
+		{"f(a: 1, ...b)", "positional argument after named argument"},
+		{"f(a: 1, a: 2)", "argument a given more than once"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -678,6 +778,44 @@ func TestCallExpressionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestTailCallMarking(t *testing.T) {
+	input := `
+	a();
+	fn() {
+		b();
+		if (x) { return c(); }
+		let y = d();
+		e(f()) + 1;
+		if (x) { g() } else { match (x) { 1 => h(), _ => { i(); j() } } }
+	};
+	fn() { select { v = ch.recv() => k(), _ => { l(); m() } } };
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	got := map[string]bool{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpression); ok {
+			got[call.Function.String()] = call.Tail
+		}
+		return true
+	})
+
+	expected := map[string]bool{
+		"a": false, "b": false, "c": true, "d": false, "e": false,
+		"f": false, "g": true, "h": true, "i": false, "j": true,
+		"k": true, "l": false, "m": true,
+	}
+	for name, tail := range expected {
+		if got[name] != tail {
+			t.Errorf("call of %s: Tail=%t, want %t", name, got[name], tail)
+		}
+	}
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral not 'let'. got=%q", s.TokenLiteral())
@@ -888,3 +1026,674 @@ func TestParsingIndexErpr(t *testing.T) {
 		return
 	}
 }
+
+func TestParsingSliceExpr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"s[1:2]", "(s[1:2])"},
+		{"s[:n + 1]", "(s[:(n + 1)])"},
+		{"s[1:]", "(s[1:])"},
+		{"s[:]", "(s[:])"},
+		{"f(s)[a[0]:][0]", "((f(s)[(a[0]):])[0])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+		if stmt.Expression.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, stmt.Expression.String())
+		}
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%d\n",
+			len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements has not 1 statements. got=%d\n",
+			len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStatement. got=%T",
+			macro.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestTypeAnnotationParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [string] = [];", "let xs: [string] = [];"},
+		{"let f: fn(int, bool) -> [int] = g;", "let f: fn(int, bool) -> [int] = g;"},
+		{"fn(a: string, b) -> bool { a }", "fn(a: string, b) -> bool a"},
+		{"fn() -> fn() -> null { x }", "fn() -> fn() -> null x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+
+	if len(hash.Keys) != len(expected) || len(hash.Values) != len(expected) {
+		t.Fatalf("hash has wrong number of pairs. got=%d", len(hash.Keys))
+	}
+
+	for i, pair := range expected {
+		literal, ok := hash.Keys[i].(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", hash.Keys[i])
+			continue
+		}
+		if literal.Value != pair.key {
+			t.Errorf("key %d wrong. want=%q, got=%q", i, pair.key, literal.Value)
+		}
+		testIntegerLiteral(t, hash.Values[i], pair.value)
+	}
+}
+
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	l := lexer.New("{}")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Keys) != 0 {
+		t.Errorf("hash has wrong number of pairs. got=%d", len(hash.Keys))
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if program.String() != `{one: (0 + 1), two: (10 - 8), three: (15 / 5)}` {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestLexicalErrorsAreReported(t *testing.T) {
+	l := lexer.New("let s = \"caf\xe9\";")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 || errors[0] != "1:13: invalid UTF-8 byte 0xe9" {
+		t.Fatalf("wrong errors. got=%q", errors)
+	}
+}
+
+func TestBigIntegerLiteral(t *testing.T) {
+	l := lexer.New("9223372036854775808;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Big == nil || literal.Big.String() != "9223372036854775808" {
+		t.Errorf("literal.Big wrong. got=%v", literal.Big)
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	l := lexer.New("3.25;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 3.25 {
+		t.Errorf("literal.Value not %v. got=%v", 3.25, literal.Value)
+	}
+}
+
+func TestStructParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Empty {};", "struct Empty {  }"},
+		{"struct P { x, y, } let p = P(1, 2);", "struct P { x, y }let p = P(1, 2);"},
+		{"p.x = p.x + 1;", "(p.x) = ((p.x) + 1);"},
+		{"a.b.c = f(1)", "((a.b).c) = f(1);"},
+		{"type Point { x, y }", "type Point { x, y }"},
+		{"impl P { fn norm(self) { self.x }; fn add(self, o) { self.x + o } }", "impl P { fn norm(self) (self.x) fn add(self, o) ((self.x) + o) }"},
+		{"impl P {}; let q = 1;", "impl P {  }let q = 1;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct P { x, x }", "duplicate field x in struct P"},
+		{"struct { x }", "expected next token to be IDENT, got {"},
+		{"struct P { 1 }", "expected next token to be IDENT, got INT"},
+		{"x = 1;", "cannot assign to x"},
+		{"a[0] = 1;", "cannot assign to (a[0])"},
+		{"impl P { fn(self) { 1 } }", "expected next token to be IDENT, got ("},
+		{"impl P { let x = 1; }", "expected next token to be FUNCTION, got LET"},
+		{"impl P { fn a(self) { 1 } fn a(self) { 2 } }", "duplicate method a in impl P"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestAssignToUnparsedTarget(t *testing.T) {
+	l := lexer.New(") = 1;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	expected := "no prefix parse function for ) found"
+	if len(errors) != 1 || errors[0] != expected {
+		t.Errorf("expected only error %q, got %q", expected, errors)
+	}
+}
+
+func TestEnumParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Result { Ok(value), Err(message) }", "enum Result { Ok(value), Err(message) }"},
+		{"enum Option { Some(v), None, }; let x = 1;", "enum Option { Some(v), None }let x = 1;"},
+		{"enum Shape { Rect(w, h), Dot() }", "enum Shape { Rect(w, h), Dot }"},
+		{"match (r) { Result.Ok(v) => v, Result.Err(_) => 0, Option.None => 1 }", "match (r) { Result.Ok(v) => v, Result.Err(_) => 0, Option.None => 1 }"},
+		{"match (r) { Shape.Rect([a, b], 2) => a }", "match (r) { Shape.Rect([a, b], 2) => a }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum R { A, A }", "duplicate variant A in enum R"},
+		{"enum R { A(x, x) }", "duplicate field x in variant A"},
+		{"enum R { 1 }", "expected next token to be IDENT, got INT"},
+		{"enum R { A(1) }", "expected next token to be IDENT, got INT"},
+		{"match (r) { R.1 => 0 }", "expected next token to be IDENT, got INT"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestSpawnAndSelectParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"spawn fn() { 1 }", "spawn fn() 1"},
+		{"let t = spawn work(ch, 2); await(t)", "let t = spawn work(ch, 2);await(t)"},
+		{"spawn f + 1", "(spawn f + 1)"},
+		{"select { v = a.recv() => v, b.send(1) => 0, _ => { 2 } }", "select { v = a.recv() => v, b.send(1) => 0, _ => 2 }"},
+		{"select { chans[0].recv() => 1 }", "select { (chans[0]).recv() => 1 }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"select { a => 1 }", "select case must be ch.recv() or ch.send(value), got a"},
+		{"select { a.recv(1) => 1 }", "select case must be ch.recv() or ch.send(value), got (a.recv)(1)"},
+		{"select { v = a.send(1) => 1 }", "cannot assign the result of send to v"},
+		{"select { _ => 1, _ => 2 }", "select has more than one default case"},
+		{"select { a.recv() 1 }", "expected next token to be =>, got INT"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestForAndYieldParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (x in xs) { f(x) }", "for (x in xs) f(x)"},
+		{"for ([i, x] in enumerate(xs)) { x };", "for ([i, x] in enumerate(xs)) x"},
+		{"fn() { yield 1 + 2; yield f(x) }", "fn() yield (1 + 2)yield f(x)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestRangeParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1..10", "(1..10)"},
+		{"0..=n - 1", "(0..=(n - 1))"},
+		{"a == 1..3", "(a == (1..3))"},
+		{"xs[1..2]", "(xs[(1..2)])"},
+		{"f(...1..n)", "f(...(1..n))"},
+		{"xs[:-1]", "(xs[:(-1)])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestPipeParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs |> map(_, f) |> sum", "((xs |> map(_, f)) |> sum)"},
+		{"xs |> len < 3", "((xs |> len) < 3)"},
+		{"a + b |> f(1)", "((a + b) |> f(1))"},
+		{"1..10 |> collect", "((1..10) |> collect)"},
+		{"x |> f >> g", "(x |> (f >> g))"},
+		{"a == x |> f", "(a == (x |> f))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestPipeCall(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x |> f", "f(x)"},
+		{"x |> f(1)", "f(x, 1)"},
+		{"x |> f(1, _)", "f(1, x)"},
+		{"x |> f(_, b: 2)", "f(x, b: 2)"},
+		{"x |> m.g(y)", "(m.g)(x, y)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		pipe, ok := stmt.Expression.(*ast.PipeExpr)
+		if !ok {
+			t.Fatalf("exp not *ast.PipeExpr. got=%T", stmt.Expression)
+		}
+		if got := pipe.Call().String(); got != tt.expected {
+			t.Errorf("%q: expected call %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestPipeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x |> f(_, _)", "more than one placeholder in f(_, _)"},
+		{"x |>", "no prefix parse function for EOF found"},
+		{"x | f", "no prefix parse function for ILLEGAL found"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestGeneratorFlag(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []bool
+	}{
+		{"fn() { 1 }", []bool{false}},
+		{"fn() { yield 1 }", []bool{true}},
+		{"fn() { fn() { yield 1 } }", []bool{false, true}},
+		{"fn() { fn() { 1 }; yield 2 }", []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		got := []bool{}
+		ast.Inspect(program, func(n ast.Node) bool {
+			if fn, ok := n.(*ast.FunctionLiteral); ok {
+				got = append(got, fn.Generator)
+			}
+			return true
+		})
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("%q: expected generators %v, got %v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestForAndYieldErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yield 1", "yield outside a function"},
+		{"for (x in range(3)) { yield x }", "yield outside a function"},
+		{"for x in xs { x }", "expected next token to be (, got IDENT"},
+		{"for (x of xs) { x }", "expected next token to be IN, got IDENT"},
+		{"for (x in xs) x", "expected next token to be {, got IDENT"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestMemberExprParsing(t *testing.T) {
+	l := lexer.New("math.sqrt(2)")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.CallExpression. got=%T", stmt.Expression)
+	}
+	member, ok := call.Function.(*ast.MemberExpr)
+	if !ok {
+		t.Fatalf("function not *ast.MemberExpr. got=%T", call.Function)
+	}
+	if !testIdentifier(t, member.Object, "math") || !testIdentifier(t, member.Property, "sqrt") {
+		return
+	}
+
+	p = New(lexer.New("math.1"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected an error for a member that is not an identifier")
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"match (x) { 0 => a, -1 => b, _ => c }",
+			"match (x) { 0 => a, (-1) => b, _ => c }",
+		},
+		{
+			"match (xs) { [] => 0, [first, ...rest] => first + 1, [_, ..._] => 2, }",
+			"match (xs) { [] => 0, [first, ...rest] => (first + 1), [_, ..._] => 2 }",
+		},
+		{
+			`match (h) { {"kind": k, 1: [y]} => k, {} => "empty" }`,
+			"match (h) { {kind: k, 1: [y]} => k, {} => empty }",
+		},
+		{
+			"match (n) { x if x > 10 => { let y = x; y } n => n }",
+			"match (n) { x if (x > 10) => let y = x;y, n => n }",
+		},
+		{
+			"match (f(1)) { true => 1, 2.5 => 2 } + 1",
+			"(match (f(1)) { true => 1, 2.5 => 2 } + 1)",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestMatchExpressionStructure(t *testing.T) {
+	l := lexer.New("match (v) { [a, ...r] if a => a, {\"k\": _} => 1 }")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+	match, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.MatchExpression. got=%T", stmt.Expression)
+	}
+	if len(match.Arms) != 2 {
+		t.Fatalf("wrong number of arms. got=%d", len(match.Arms))
+	}
+
+	arr, ok := match.Arms[0].Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("pattern not *ast.ArrayPattern. got=%T", match.Arms[0].Pattern)
+	}
+	if len(arr.Elements) != 1 || arr.Rest == nil || match.Arms[0].Guard == nil {
+		t.Errorf("wrong array arm. got=%s", match.Arms[0])
+	}
+
+	hash, ok := match.Arms[1].Pattern.(*ast.HashPattern)
+	if !ok {
+		t.Fatalf("pattern not *ast.HashPattern. got=%T", match.Arms[1].Pattern)
+	}
+	if _, ok := hash.Values[0].(*ast.WildcardPattern); !ok {
+		t.Errorf("value pattern not *ast.WildcardPattern. got=%T", hash.Values[0])
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { a + 1 => 2 }", "expected next token to be =>, got +"},
+		{"match (x) { * => 2 }", "expected a pattern, got *"},
+		{"match (x) { [...[a]] => 2 }", "expected a name or _ after ..., got [a]"},
+		{"match (x) { [...a, b] => 2 }", "expected next token to be ], got ,"},
+		{"match (x) { {[1]: 1} => 2 }", "expected a literal hash key, got ["},
+		{"let [a, b + 1] = xs;", "expected next token to be ], got +"},
+		{"let {a: 1 = 2} = h;", "expected next token to be }, got ="},
+		{"match (x) { 1 => 2 3 => 4 }", "expected next token to be }, got INT"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected first error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+)
+
+func (p *Parser) parsePipeExpr(left ast.Expression) ast.Expression {
+	exp := &ast.PipeExpr{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Right = p.parseExpression(PIPE)
+	if exp.Right == nil {
+		return nil
+	}
+
+	if call, ok := exp.Right.(*ast.CallExpression); ok {
+		placeholders := 0
+		for _, arg := range call.Arguments {
+			if ast.IsPlaceholder(arg) {
+				placeholders++
+			}
+		}
+		if placeholders > 1 {
+			msg := fmt.Sprintf("more than one placeholder in %s", call)
+			p.errors = append(p.errors, msg)
+		}
+	}
+
+	return exp
+}
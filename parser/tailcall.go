@@ -0,0 +1,54 @@
+package parser
+
+import "monkey/ast"
+
+// markTailCalls sets Tail on the calls in tail position in the body of
+// fn: the values of its return statements and its final expression,
+// looking through if, match and select expressions. Nested functions
+// are marked when they are parsed. Generators are left alone: their body
+// runs apart from the call, which has long returned an iterator.
+func markTailCalls(fn *ast.FunctionLiteral) {
+	if fn.Body == nil || fn.Generator {
+		return
+	}
+
+	markTailBlock(fn.Body)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral, *ast.MacroLiteral:
+			return false
+		case *ast.ReturnStatement:
+			markTail(n.ReturnValue)
+		}
+		return true
+	})
+}
+
+func markTailBlock(block *ast.BlockStatement) {
+	if block == nil || len(block.Statements) == 0 {
+		return
+	}
+	if stmt, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement); ok {
+		markTail(stmt.Expression)
+	}
+}
+
+func markTail(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.CallExpression:
+		if name := exp.Function.TokenLiteral(); name != "quote" && name != "unquote" {
+			exp.Tail = true
+		}
+	case *ast.IfExpression:
+		markTailBlock(exp.Consequence)
+		markTailBlock(exp.Alternative)
+	case *ast.MatchExpression:
+		for _, arm := range exp.Arms {
+			markTailBlock(arm.Body)
+		}
+	case *ast.SelectExpression:
+		for _, c := range exp.Cases {
+			markTailBlock(c.Body)
+		}
+	}
+}
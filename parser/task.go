@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (p *Parser) parseSpawnExpr() ast.Expression {
+	exp := &ast.SpawnExpr{Token: p.curToken}
+
+	p.nextToken()
+	exp.Call = p.parseExpression(PREFIX)
+	if exp.Call == nil {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseSelectExpression() ast.Expression {
+	exp := &ast.SelectExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	hasDefault := false
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		c := p.parseSelectCase()
+		if c == nil {
+			return nil
+		}
+		if c.Channel == nil {
+			if hasDefault {
+				p.errors = append(p.errors, "select has more than one default case")
+			}
+			hasDefault = true
+		}
+		exp.Cases = append(exp.Cases, c)
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		} else if !p.curTokenIs(token.RBRACE) {
+			break
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseSelectCase() *ast.SelectCase {
+	c := &ast.SelectCase{Token: p.curToken}
+
+	if p.curTokenIs(token.IDENT) && p.curToken.Literal == "_" && p.peekTokenIs(token.FAT_ARROW) {
+		c.Body = p.parseArmBody()
+		if c.Body == nil {
+			return nil
+		}
+		return c
+	}
+
+	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
+		c.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	op := p.parseExpression(LOWEST)
+	if op == nil {
+		return nil
+	}
+	call, _ := op.(*ast.CallExpression)
+	var member *ast.MemberExpr
+	if call != nil {
+		member, _ = call.Function.(*ast.MemberExpr)
+	}
+	switch {
+	case member != nil && member.Property.Value == "recv" && len(call.Arguments) == 0:
+		c.Channel = member.Object
+	case member != nil && member.Property.Value == "send" && len(call.Arguments) == 1 && c.Name == nil:
+		c.Channel = member.Object
+		c.Value = call.Arguments[0]
+	case member != nil && member.Property.Value == "send" && c.Name != nil:
+		msg := fmt.Sprintf("cannot assign the result of send to %s", c.Name.Value)
+		p.errors = append(p.errors, msg)
+		return nil
+	default:
+		msg := fmt.Sprintf("select case must be ch.recv() or ch.send(value), got %s", op)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	c.Body = p.parseArmBody()
+	if c.Body == nil {
+		return nil
+	}
+
+	return c
+}
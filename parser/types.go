@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+// parseOptionalAnnotation parses a `: type` suffix after an identifier if
+// there is one.
+func (p *Parser) parseOptionalAnnotation() ast.TypeExpr {
+	if !p.peekTokenIs(token.COLON) {
+		return nil
+	}
+
+	p.nextToken()
+	p.nextToken()
+	return p.parseType()
+}
+
+func (p *Parser) parseType() ast.TypeExpr {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+	case token.LBRACKET:
+		t := &ast.ArrayType{Token: p.curToken}
+		p.nextToken()
+		t.Element = p.parseType()
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return t
+	case token.FUNCTION:
+		return p.parseFunctionType()
+	default:
+		msg := fmt.Sprintf("expected a type, got %s", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+func (p *Parser) parseFunctionType() ast.TypeExpr {
+	t := &ast.FunctionType{Token: p.curToken, Parameters: []ast.TypeExpr{}}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		t.Parameters = append(t.Parameters, p.parseType())
+
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			t.Parameters = append(t.Parameters, p.parseType())
+		}
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	p.nextToken()
+	t.Return = p.parseType()
+
+	return t
+}
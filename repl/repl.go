@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"monkey/ast"
 	"monkey/evaluator"
 	"monkey/lexer"
 	"monkey/object"
@@ -13,34 +14,61 @@ import (
 const PROMPT = ">> "
 
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	reader := bufio.NewReader(in)
 	env := object.NewEnvironment()
+	macroEnv := object.NewEnvironment()
 
 	for {
 		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		// ReadString, unlike a Scanner, has no limit on the length
+		// of a line.
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			fmt.Fprintf(out, "error reading input: %s\n", err)
 			return
 		}
-
-		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
-
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
-			continue
+		if line == "" && err == io.EOF {
+			return
 		}
 
-		eval := evaluator.Eval(program, env)
-		if eval != nil {
-			io.WriteString(out, eval.Inspect())
-			io.WriteString(out, "\n")
+		evalLine(out, line, env, macroEnv)
+		if err == io.EOF {
+			return
 		}
 	}
 }
 
+// evalLine parses and evaluates one line of input in env.
+func evalLine(out io.Writer, line string, env, macroEnv *object.Environment) {
+	l := lexer.New(line)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return
+	}
+
+	expanded, err := expandMacros(program, macroEnv)
+	if err != nil {
+		printParserErrors(out, []string{err.Error()})
+		return
+	}
+	evaluator.Resolve(expanded)
+
+	eval := evaluator.Eval(expanded, env)
+	if eval != nil {
+		io.WriteString(out, evaluator.Inspect(eval))
+		io.WriteString(out, "\n")
+	}
+}
+
+// expandMacros defines and expands the macros of program.
+func expandMacros(program *ast.Program, macroEnv *object.Environment) (ast.Node, error) {
+	evaluator.DefineMacros(program, macroEnv)
+	return evaluator.ExpandMacros(program, macroEnv)
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
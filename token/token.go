@@ -3,8 +3,10 @@ package token
 type TokenType string
 
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Line    int       `json:"line,omitempty"`
+	Column  int       `json:"column,omitempty"`
 }
 
 const (
@@ -14,6 +16,7 @@ const (
 	STRING = "STRING"
 	IDENT  = "IDENT"
 	INT    = "INT"
+	FLOAT  = "FLOAT"
 
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -21,6 +24,10 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	SHL      = "<<"
+	SHR      = ">>"
+	PIPE     = "|>"
 
 	LT     = "<"
 	GT     = ">"
@@ -29,6 +36,13 @@ const (
 
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	DOT       = "."
+	ARROW     = "->"
+	FAT_ARROW = "=>"
+	ELLIPSIS  = "..."
+	DOTDOT    = ".."
+	DOTDOT_EQ = "..="
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -44,6 +58,17 @@ const (
 	RETURN   = "RETURN"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
+	MACRO    = "MACRO"
+	MATCH    = "MATCH"
+	STRUCT   = "STRUCT"
+	TYPE     = "TYPE"
+	IMPL     = "IMPL"
+	ENUM     = "ENUM"
+	SPAWN    = "SPAWN"
+	SELECT   = "SELECT"
+	YIELD    = "YIELD"
+	FOR      = "FOR"
+	IN       = "IN"
 )
 
 var keywords = map[string]TokenType{
@@ -54,6 +79,17 @@ var keywords = map[string]TokenType{
 	"return": RETURN,
 	"true":   TRUE,
 	"false":  FALSE,
+	"macro":  MACRO,
+	"match":  MATCH,
+	"struct": STRUCT,
+	"type":   TYPE,
+	"impl":   IMPL,
+	"enum":   ENUM,
+	"spawn":  SPAWN,
+	"select": SELECT,
+	"yield":  YIELD,
+	"for":    FOR,
+	"in":     IN,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,1096 @@
+package types
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"strings"
+)
+
+// Error is a type error at a position in the source.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Info records the types inferred for a program.
+type Info struct {
+	// Defs maps every identifier bound by a let statement, a function
+	// parameter, a pattern or a struct declaration to its type; for a
+	// struct that is the type of its constructor.
+	Defs map[*ast.Identifier]Type
+}
+
+type env struct {
+	outer *env
+	names map[string]*scheme
+}
+
+func newEnv(outer *env) *env {
+	return &env{outer: outer, names: map[string]*scheme{}}
+}
+
+func (e *env) lookup(name string) (*scheme, bool) {
+	for ; e != nil; e = e.outer {
+		if s, ok := e.names[name]; ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+type checker struct {
+	nextVar int
+	env     *env
+	returns []Type
+	info    *Info
+	errors  []Error
+
+	// yields holds the element types of the generators being checked,
+	// innermost last.
+	yields []Type
+
+	// impls holds the method names of every impl block in the program
+	// by struct name, so that a method used before its impl block is
+	// not reported as missing.
+	impls map[string]map[string]bool
+}
+
+// Check infers the types of program and reports every type error it finds.
+func Check(program *ast.Program) (*Info, []Error) {
+	c := &checker{env: newEnv(nil), info: &Info{Defs: map[*ast.Identifier]Type{}}}
+	c.declareBuiltins()
+	c.collectImpls(program)
+
+	for _, stmt := range program.Statements {
+		c.statement(stmt)
+	}
+
+	for ident, t := range c.info.Defs {
+		c.info.Defs[ident] = resolve(t)
+	}
+
+	return c.info, c.errors
+}
+
+func (c *checker) collectImpls(program *ast.Program) {
+	c.impls = map[string]map[string]bool{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if impl, ok := n.(*ast.ImplStatement); ok {
+			names := c.impls[impl.Target.Value]
+			if names == nil {
+				names = map[string]bool{}
+				c.impls[impl.Target.Value] = names
+			}
+			for _, name := range impl.Names {
+				names[name.Value] = true
+			}
+		}
+		return true
+	})
+}
+
+func (c *checker) declareBuiltins() {
+	a := c.fresh()
+	c.env.names["len"] = &scheme{vars: []*Var{a}, t: &Function{Params: []Type{a}, Return: Int}}
+
+	fn := func(ret Type, params ...Type) *scheme {
+		return &scheme{t: &Function{Params: params, Return: ret}}
+	}
+	c.env.names["split"] = fn(&Array{Element: String}, String, String)
+	c.env.names["join"] = fn(String, &Array{Element: String}, String)
+	c.env.names["trim"] = fn(String, String)
+	c.env.names["upper"] = fn(String, String)
+	c.env.names["lower"] = fn(String, String)
+	c.env.names["replace"] = fn(String, String, String, String)
+	c.env.names["starts_with"] = fn(Bool, String, String)
+	c.env.names["ends_with"] = fn(Bool, String, String)
+	c.env.names["repeat"] = fn(String, String, Int)
+	c.env.names["range"] = &scheme{t: &Function{Params: []Type{Int, Int, Int}, Return: &Iterator{Element: Int}, Optional: 2}}
+}
+
+func (c *checker) fresh() *Var {
+	c.nextVar++
+	return &Var{id: c.nextVar}
+}
+
+func (c *checker) errorf(tok token.Token, format string, a ...interface{}) {
+	c.errors = append(c.errors, Error{
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (c *checker) unify(tok token.Token, want, got Type) {
+	if err := unify(want, got); err != nil {
+		c.errorf(tok, "%s", err)
+	}
+}
+
+// join returns the type of a value that is either a or b: their unified
+// type if they unify and Dynamic otherwise. A nil a is ignored. Unlike
+// unify, join reports no error and leaves no variable bound on failure.
+func (c *checker) join(a, b Type) Type {
+	if a == nil {
+		return b
+	}
+
+	vars := append(freeVars(a), freeVars(b)...)
+	numeric := make([]bool, len(vars))
+	for i, v := range vars {
+		numeric[i] = v.numeric
+	}
+	if err := unify(a, b); err != nil {
+		for i, v := range vars {
+			v.instance, v.numeric = nil, numeric[i]
+		}
+		return Dynamic
+	}
+	return a
+}
+
+// orFresh returns t, or a fresh variable if t is nil.
+func (c *checker) orFresh(t Type) Type {
+	if t == nil {
+		return c.fresh()
+	}
+	return t
+}
+
+func (c *checker) statement(stmt ast.Statement) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		c.let(stmt)
+		return Null
+	case *ast.ReturnStatement:
+		t := c.expression(stmt.ReturnValue)
+		if len(c.returns) > 0 {
+			c.unify(stmt.Token, c.returns[len(c.returns)-1], t)
+		}
+		// Control does not continue past a return, so the statement
+		// itself may be used at any type.
+		return c.fresh()
+	case *ast.StructStatement:
+		c.structDecl(stmt)
+		return Null
+	case *ast.ImplStatement:
+		c.impl(stmt)
+		return Null
+	case *ast.EnumStatement:
+		c.enumDecl(stmt)
+		return Null
+	case *ast.AssignStatement:
+		c.unify(stmt.Token, c.expression(stmt.Target), c.expression(stmt.Value))
+		return Null
+	case *ast.ForStatement:
+		c.forStatement(stmt)
+		return Null
+	case *ast.ExpressionStatement:
+		return c.expression(stmt.Expression)
+	case *ast.BlockStatement:
+		return c.block(stmt)
+	}
+	return c.fresh()
+}
+
+func (c *checker) block(block *ast.BlockStatement) Type {
+	var t Type = Null
+	for _, stmt := range block.Statements {
+		t = c.statement(stmt)
+	}
+	return t
+}
+
+func (c *checker) let(stmt *ast.LetStatement) {
+	if stmt.Pattern != nil {
+		c.pattern(stmt.Pattern, c.expression(stmt.Value))
+		return
+	}
+
+	name := stmt.Name
+
+	var annotated Type
+	if name.Type != nil {
+		annotated = c.typeFromAnnotation(name.Type)
+	}
+
+	var t Type
+	if _, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		// Bind the name monomorphically first so the function can
+		// call itself.
+		self := c.fresh()
+		c.env.names[name.Value] = &scheme{t: self}
+		t = c.expression(stmt.Value)
+		c.unify(stmt.Value.(*ast.FunctionLiteral).Token, self, t)
+	} else if annotated != nil {
+		c.expect(name.Token, stmt.Value, annotated)
+	} else {
+		t = c.expression(stmt.Value)
+	}
+
+	if annotated != nil {
+		if t != nil {
+			c.unify(name.Token, annotated, t)
+		}
+		t = annotated
+	}
+
+	c.info.Defs[name] = t
+	delete(c.env.names, name.Value)
+	c.env.names[name.Value] = c.generalize(t)
+}
+
+// expect checks exp against want, the type an annotation gives it. The
+// elements of an array literal are checked against the element type one
+// by one, so that they cannot join to Dynamic.
+func (c *checker) expect(tok token.Token, exp ast.Expression, want Type) {
+	if exp, ok := exp.(*ast.ArrayLiteral); ok {
+		if a, ok := prune(want).(*Array); ok {
+			for _, el := range exp.Elements {
+				c.expect(exp.Token, el, a.Element)
+			}
+			return
+		}
+	}
+	c.unify(tok, want, c.expression(exp))
+}
+
+func (c *checker) expression(exp ast.Expression) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.Identifier:
+		if s, ok := c.env.lookup(exp.Value); ok {
+			return c.instantiate(s)
+		}
+		// Undefined names are the linter's business; they may be
+		// anything as far as types are concerned.
+		return c.fresh()
+	case *ast.PrefixExpression:
+		return c.prefix(exp)
+	case *ast.InfixExpression:
+		return c.infix(exp)
+	case *ast.IfExpression:
+		return c.ifExpression(exp)
+	case *ast.FunctionLiteral:
+		return c.function(exp)
+	case *ast.CallExpression:
+		return c.call(exp)
+	case *ast.ArrayLiteral:
+		var elem Type
+		for _, el := range exp.Elements {
+			elem = c.join(elem, c.expression(el))
+		}
+		return &Array{Element: c.orFresh(elem)}
+	case *ast.HashLiteral:
+		var key, value Type
+		for i := range exp.Keys {
+			key = c.join(key, c.expression(exp.Keys[i]))
+			value = c.join(value, c.expression(exp.Values[i]))
+		}
+		return &Hash{Key: c.orFresh(key), Value: c.orFresh(value)}
+	case *ast.IndexExpr:
+		return c.index(exp)
+	case *ast.SliceExpr:
+		return c.slice(exp)
+	case *ast.PipeExpr:
+		return c.call(exp.Call())
+	case *ast.RangeExpr:
+		c.unify(exp.Token, Int, c.expression(exp.Start))
+		c.unify(exp.Token, Int, c.expression(exp.End))
+		return Range
+	case *ast.MatchExpression:
+		return c.match(exp)
+	case *ast.SpawnExpr:
+		// Tasks and channels are untyped; only the call is checked.
+		c.expression(exp.Call)
+		return c.fresh()
+	case *ast.SelectExpression:
+		return c.selectExpression(exp)
+	case *ast.YieldExpr:
+		t := c.expression(exp.Value)
+		if len(c.yields) > 0 {
+			c.unify(exp.Token, c.yields[len(c.yields)-1], t)
+		}
+		return Null
+	case *ast.MemberExpr:
+		return c.member(exp)
+	}
+	return c.fresh()
+}
+
+func (c *checker) index(exp *ast.IndexExpr) Type {
+	left := c.expression(exp.Left)
+	index := c.expression(exp.Index)
+	elem := c.fresh()
+
+	switch l := prune(left).(type) {
+	case *Struct:
+		if m, ok := prune(l.Methods["index"]).(*Function); ok && len(m.Params) == 2 {
+			c.unify(exp.Token, m.Params[1], index)
+			return m.Return
+		}
+	case *Hash:
+		c.unify(exp.Token, l.Key, index)
+		return l.Value
+	case *Basic:
+		switch l {
+		case String:
+			c.unify(exp.Token, Int, index)
+			return String
+		case Range:
+			c.unify(exp.Token, Int, index)
+			return Int
+		}
+	}
+
+	c.unify(exp.Token, &Array{Element: elem}, left)
+	c.unify(exp.Token, Int, index)
+	return elem
+}
+
+// structDecl binds the name of a struct to its constructor, whose
+// parameters are the types of the fields.
+func (c *checker) structDecl(stmt *ast.StructStatement) {
+	s := &Struct{Name: stmt.Name.Value, Fields: map[string]Type{}, Methods: map[string]Type{}}
+	params := make([]Type, len(stmt.Fields))
+	for i, f := range stmt.Fields {
+		params[i] = c.fresh()
+		s.Fields[f.Value] = params[i]
+	}
+
+	t := &Function{Params: params, Return: s}
+	c.info.Defs[stmt.Name] = t
+	c.env.names[stmt.Name.Value] = &scheme{t: t}
+}
+
+// impl types the methods of an impl block. The first parameter of each
+// method is the instance, so it is unified with the struct.
+func (c *checker) impl(stmt *ast.ImplStatement) {
+	var s *Struct
+	if fn, ok := prune(c.expression(stmt.Target)).(*Function); ok {
+		s, _ = prune(fn.Return).(*Struct)
+	}
+	if s == nil {
+		c.errorf(stmt.Target.Token, "%s is not a struct", stmt.Target.Value)
+		for _, m := range stmt.Methods {
+			c.function(m)
+		}
+		return
+	}
+
+	// Declare every method first so that they can call each other.
+	for _, name := range stmt.Names {
+		s.Methods[name.Value] = c.fresh()
+	}
+	for i, m := range stmt.Methods {
+		name := stmt.Names[i]
+		if len(m.Parameters) == 0 {
+			c.errorf(name.Token, "method %s needs a parameter for the instance", name.Value)
+			continue
+		}
+		t := c.method(m, s)
+		c.unify(name.Token, s.Methods[name.Value], t)
+		c.info.Defs[name] = t
+	}
+}
+
+// enumDecl binds the name of an enum to its declaration, whose members
+// are the variants.
+func (c *checker) enumDecl(stmt *ast.EnumStatement) {
+	e := &Enum{Name: stmt.Name.Value, Variants: map[string][]Type{}}
+	for _, v := range stmt.Variants {
+		fields := make([]Type, len(v.Fields))
+		for i := range v.Fields {
+			fields[i] = c.fresh()
+		}
+		e.Names = append(e.Names, v.Name.Value)
+		e.Variants[v.Name.Value] = fields
+	}
+
+	t := &EnumDecl{Enum: e}
+	c.info.Defs[stmt.Name] = t
+	c.env.names[stmt.Name.Value] = &scheme{t: t}
+}
+
+// variant types a variant of e: a constructor if it has fields, and
+// otherwise a value of e.
+func (c *checker) variant(name *ast.Identifier, e *Enum) Type {
+	fields, ok := e.Variants[name.Value]
+	if !ok {
+		c.errorf(name.Token, "%s has no variant %s", e.Name, name.Value)
+		return c.fresh()
+	}
+	if len(fields) == 0 {
+		return e
+	}
+	return &Function{Params: fields, Return: e}
+}
+
+// member types field access and method lookup on structs. Other
+// members, such as those of modules, may be anything.
+func (c *checker) member(exp *ast.MemberExpr) Type {
+	obj := prune(c.expression(exp.Object))
+	if d, ok := obj.(*EnumDecl); ok {
+		return c.variant(exp.Property, d.Enum)
+	}
+	s, ok := obj.(*Struct)
+	if !ok {
+		return c.fresh()
+	}
+
+	name := exp.Property.Value
+	if t, ok := s.Fields[name]; ok {
+		return t
+	}
+	if t, ok := s.Methods[name]; ok {
+		if fn, ok := prune(t).(*Function); ok && len(fn.Params) > 0 {
+			return &Function{Params: fn.Params[1:], Return: fn.Return, Optional: fn.Optional, Variadic: fn.Variadic}
+		}
+		return c.fresh()
+	}
+	if c.impls[s.Name][name] {
+		return c.fresh()
+	}
+	c.errorf(exp.Property.Token, "%s has no field or method %s", s.Name, name)
+	return c.fresh()
+}
+
+// match checks each arm in a scope of its own holding its pattern's
+// bindings. Arms of different types make the match dynamic.
+func (c *checker) match(exp *ast.MatchExpression) Type {
+	subject := c.expression(exp.Subject)
+	var result Type
+
+	outer := c.env
+	for _, arm := range exp.Arms {
+		c.env = newEnv(outer)
+		c.pattern(arm.Pattern, subject)
+		if arm.Guard != nil {
+			c.expression(arm.Guard)
+		}
+		result = c.join(result, c.block(arm.Body))
+	}
+	c.env = outer
+
+	if e, ok := prune(subject).(*Enum); ok {
+		c.exhaustive(exp, e)
+	}
+
+	return c.orFresh(result)
+}
+
+func (c *checker) pattern(p ast.Pattern, t Type) {
+	switch p := p.(type) {
+	case *ast.BindingPattern:
+		if p.Default != nil {
+			c.unify(p.Token, t, c.expression(p.Default))
+		}
+		c.env.names[p.Name.Value] = &scheme{t: t}
+		c.info.Defs[p.Name] = t
+	case *ast.LiteralPattern:
+		c.unify(p.Token, t, c.expression(p.Value))
+	case *ast.ArrayPattern:
+		elem := c.sequence(p.Token, t)
+		for _, el := range p.Elements {
+			c.pattern(el, elem)
+		}
+		if p.Rest != nil {
+			c.pattern(p.Rest, &Array{Element: elem})
+		}
+	case *ast.VariantPattern:
+		d, ok := prune(c.expression(p.Enum)).(*EnumDecl)
+		if !ok {
+			c.errorf(p.Token, "%s is not an enum", p.Enum.Value)
+			return
+		}
+		c.unify(p.Token, d.Enum, t)
+		fields, ok := d.Enum.Variants[p.Variant.Value]
+		if !ok {
+			c.errorf(p.Variant.Token, "%s has no variant %s", d.Enum.Name, p.Variant.Value)
+			return
+		}
+		if len(p.Args) > 0 && len(p.Args) != len(fields) {
+			c.errorf(p.Token, "wrong number of fields in pattern %s: want=%d, got=%d", p, len(fields), len(p.Args))
+			return
+		}
+		for i, arg := range p.Args {
+			c.pattern(arg, fields[i])
+		}
+	case *ast.HashPattern:
+		var key, value Type
+		for i := range p.Keys {
+			key = c.join(key, c.expression(p.Keys[i]))
+			v := c.fresh()
+			c.pattern(p.Values[i], v)
+			value = c.join(value, v)
+		}
+		c.unify(p.Token, &Hash{Key: c.orFresh(key), Value: c.orFresh(value)}, t)
+	}
+}
+
+// selectExpression checks each case in a scope of its own holding the
+// name it receives into, if any. Cases of different types make the
+// select dynamic.
+func (c *checker) selectExpression(exp *ast.SelectExpression) Type {
+	var result Type
+
+	outer := c.env
+	for _, sc := range exp.Cases {
+		c.env = newEnv(outer)
+		if sc.Channel != nil {
+			c.expression(sc.Channel)
+		}
+		if sc.Value != nil {
+			c.expression(sc.Value)
+		}
+		if sc.Name != nil {
+			t := c.fresh()
+			c.env.names[sc.Name.Value] = &scheme{t: t}
+			c.info.Defs[sc.Name] = t
+		}
+		result = c.join(result, c.block(sc.Body))
+	}
+	c.env = outer
+
+	return c.orFresh(result)
+}
+
+// forStatement binds the loop target to the element type of what it
+// iterates over: the elements of an array, range or iterator, the
+// characters of a string, or anything for other types, such as structs
+// with an iter method.
+func (c *checker) forStatement(stmt *ast.ForStatement) {
+	var elem Type
+	switch t := prune(c.expression(stmt.Iterable)).(type) {
+	case *Array:
+		elem = t.Element
+	case *Iterator:
+		elem = t.Element
+	case *Basic:
+		switch t {
+		case String:
+			elem = String
+		case Range:
+			elem = Int
+		}
+	}
+	if elem == nil {
+		elem = c.fresh()
+	}
+
+	c.pattern(stmt.Target, elem)
+	c.block(stmt.Body)
+}
+
+// sequence returns the element type of t, which is spread or destructured
+// like an array.
+func (c *checker) sequence(tok token.Token, t Type) Type {
+	switch t := prune(t).(type) {
+	case *Iterator:
+		return t.Element
+	case *Basic:
+		if t == Range {
+			return Int
+		}
+	}
+
+	elem := c.fresh()
+	c.unify(tok, &Array{Element: elem}, t)
+	return elem
+}
+
+// exhaustive reports a match on a value of e whose arms do not cover
+// every variant. An arm covers a variant if it has no guard and its
+// pattern matches every value of the variant.
+func (c *checker) exhaustive(exp *ast.MatchExpression, e *Enum) {
+	covered := map[string]bool{}
+	for _, arm := range exp.Arms {
+		if arm.Guard != nil {
+			continue
+		}
+		switch p := arm.Pattern.(type) {
+		case *ast.WildcardPattern, *ast.BindingPattern:
+			return
+		case *ast.VariantPattern:
+			if irrefutable(p.Args) {
+				covered[p.Variant.Value] = true
+			}
+		}
+	}
+
+	missing := []string{}
+	for _, name := range e.Names {
+		if !covered[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		c.errorf(exp.Token, "match on %s is not exhaustive: missing %s", e.Name, strings.Join(missing, ", "))
+	}
+}
+
+func irrefutable(patterns []ast.Pattern) bool {
+	for _, p := range patterns {
+		switch p.(type) {
+		case *ast.WildcardPattern, *ast.BindingPattern:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (c *checker) slice(exp *ast.SliceExpr) Type {
+	left := c.expression(exp.Left)
+	for _, bound := range []ast.Expression{exp.Start, exp.End} {
+		if bound != nil {
+			c.unify(exp.Token, Int, c.expression(bound))
+		}
+	}
+
+	if l := prune(left); l == String || l == Range {
+		return left
+	}
+	c.unify(exp.Token, &Array{Element: c.fresh()}, left)
+	return left
+}
+
+// arithmetic types an arithmetic operation on left and right: float if
+// either is a float and int if both are ints. An operand of unknown type
+// is taken to be some number rather than the type of the other operand,
+// so that fn(x) { x + 1 } takes floats as well as ints. It reports false,
+// binding nothing, if an operand cannot be a number.
+func arithmetic(left, right Type) (Type, bool) {
+	l, r := prune(left), prune(right)
+	if !mayBeNumber(l) || !mayBeNumber(r) {
+		return nil, false
+	}
+	for _, t := range []Type{l, r} {
+		if v, ok := t.(*Var); ok {
+			v.numeric = true
+		}
+	}
+
+	switch {
+	case l == Float || r == Float:
+		return Float, true
+	case l == Int:
+		return r, true
+	case r == Int:
+		return l, true
+	}
+	unify(l, r)
+	return l, true
+}
+
+func mayBeNumber(t Type) bool {
+	_, ok := t.(*Var)
+	return ok || t == Int || t == Float
+}
+
+// unknown reports whether both left and right are of unknown type, which
+// may make them strings as well as numbers.
+func unknown(left, right Type) bool {
+	_, l := prune(left).(*Var)
+	_, r := prune(right).(*Var)
+	return l && r
+}
+
+func (c *checker) prefix(exp *ast.PrefixExpression) Type {
+	right := c.expression(exp.Right)
+
+	switch exp.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if prune(right) == Dynamic {
+			return Dynamic
+		}
+		if t, ok := arithmetic(right, Int); ok {
+			return t
+		}
+		c.errorf(exp.Token, "unknown operator: -%s", resolve(right))
+		return Int
+	}
+	return c.fresh()
+}
+
+func (c *checker) infix(exp *ast.InfixExpression) Type {
+	left := c.expression(exp.Left)
+	right := c.expression(exp.Right)
+
+	mismatch := func() {
+		c.errorf(exp.Token, "type mismatch: %s %s %s", resolve(left), exp.Operator, resolve(right))
+	}
+
+	if prune(left) == Dynamic || prune(right) == Dynamic {
+		switch exp.Operator {
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+		return Dynamic
+	}
+
+	if exp.Operator == ">>" {
+		if t, ok := c.compose(exp, left, right); ok {
+			return t
+		}
+	}
+
+	switch exp.Operator {
+	case "+":
+		if !unknown(left, right) {
+			if t, ok := arithmetic(left, right); ok {
+				return t
+			}
+		}
+		if unify(left, right) != nil {
+			mismatch()
+			return c.fresh()
+		}
+		switch t := prune(left); t {
+		case Int, Float, String:
+			return t
+		default:
+			if _, ok := t.(*Var); ok {
+				return t
+			}
+			c.errorf(exp.Token, "unknown operator: %s + %s", t, t)
+			return c.fresh()
+		}
+	case "-", "*", "/", "%":
+		if t, ok := arithmetic(left, right); ok {
+			return t
+		}
+		mismatch()
+		return Int
+	case "<<", ">>":
+		if unify(Int, left) != nil || unify(Int, right) != nil {
+			mismatch()
+		}
+		return Int
+	case "<", ">":
+		if !unknown(left, right) {
+			if _, ok := arithmetic(left, right); ok {
+				return Bool
+			}
+		}
+		if unify(left, right) != nil {
+			mismatch()
+			return Bool
+		}
+		switch t := prune(left).(type) {
+		case *Basic:
+			if t != Int && t != Float && t != String {
+				c.errorf(exp.Token, "unknown operator: %s %s %s", t, exp.Operator, t)
+			}
+		case *Function, *Hash:
+			c.errorf(exp.Token, "unknown operator: %s %s %s", t, exp.Operator, t)
+		}
+		return Bool
+	case "==", "!=":
+		// Values of any two types can be compared for equality; those
+		// of different types are just not equal.
+		return Bool
+	}
+	return c.fresh()
+}
+
+// compose types f >> g if either side is a function: the result takes
+// the parameters of f and returns what g returns given the result of f.
+func (c *checker) compose(exp *ast.InfixExpression, left, right Type) (Type, bool) {
+	f, fok := prune(left).(*Function)
+	_, gok := prune(right).(*Function)
+	if !fok && !gok {
+		return nil, false
+	}
+
+	if !fok {
+		f = &Function{Params: []Type{c.fresh()}, Return: c.fresh()}
+		c.unify(exp.Token, f, left)
+	}
+	ret := c.fresh()
+	c.unify(exp.Token, &Function{Params: []Type{f.Return}, Return: ret}, right)
+
+	return &Function{Params: f.Params, Return: ret, Optional: f.Optional, Variadic: f.Variadic}, true
+}
+
+func (c *checker) ifExpression(exp *ast.IfExpression) Type {
+	c.expression(exp.Condition)
+
+	consequence := c.block(exp.Consequence)
+	if exp.Alternative == nil {
+		return Null
+	}
+
+	return c.join(consequence, c.block(exp.Alternative))
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral) Type {
+	return c.method(fn, nil)
+}
+
+// method types fn with its first parameter bound to receiver, if it is
+// not nil, so that the body sees the fields of the instance.
+func (c *checker) method(fn *ast.FunctionLiteral, receiver Type) Type {
+	outer := c.env
+	c.env = newEnv(outer)
+	defer func() { c.env = outer }()
+
+	optional := 0
+	params := make([]Type, len(fn.Parameters))
+	for i, p := range fn.Parameters {
+		switch {
+		case i == 0 && receiver != nil:
+			params[i] = receiver
+			if p.Type != nil {
+				c.unify(p.Token, c.typeFromAnnotation(p.Type), receiver)
+			}
+		case p.Type != nil:
+			params[i] = c.typeFromAnnotation(p.Type)
+		case fn.Variadic && i == len(fn.Parameters)-1:
+			params[i] = &Array{Element: c.fresh()}
+		default:
+			params[i] = c.fresh()
+		}
+		if d := fn.Default(i); d != nil {
+			c.unify(p.Token, params[i], c.expression(d))
+			optional++
+		}
+		c.env.names[p.Value] = &scheme{t: params[i]}
+		c.info.Defs[p] = params[i]
+	}
+
+	var ret Type = c.fresh()
+	if fn.ReturnType != nil {
+		ret = c.typeFromAnnotation(fn.ReturnType)
+	}
+
+	// A generator returns an iterator over what it yields; returning
+	// only ends it, with a value nobody sees.
+	if fn.Generator {
+		elem := c.fresh()
+		c.unify(fn.Token, ret, &Iterator{Element: elem})
+		c.yields = append(c.yields, elem)
+		c.returns = append(c.returns, c.fresh())
+		c.block(fn.Body)
+		c.returns = c.returns[:len(c.returns)-1]
+		c.yields = c.yields[:len(c.yields)-1]
+		return &Function{Params: params, Return: ret, Optional: optional, Variadic: fn.Variadic}
+	}
+
+	c.returns = append(c.returns, ret)
+	body := c.block(fn.Body)
+	c.returns = c.returns[:len(c.returns)-1]
+
+	c.unify(fn.Token, ret, body)
+
+	return &Function{Params: params, Return: ret, Optional: optional, Variadic: fn.Variadic}
+}
+
+func (c *checker) call(exp *ast.CallExpression) Type {
+	if ident, ok := exp.Function.(*ast.Identifier); ok {
+		if ident.Value == "quote" || ident.Value == "unquote" {
+			return c.fresh()
+		}
+	}
+
+	callee := c.expression(exp.Function)
+
+	// Spread and named arguments are checked on their own; which
+	// parameters they end up in is only known when the call runs.
+	args := []Type{}
+	exact := true
+	for _, a := range exp.Arguments {
+		switch a := a.(type) {
+		case *ast.SpreadExpr:
+			c.sequence(a.Token, c.expression(a.Value))
+			exact = false
+		case *ast.NamedArgument:
+			c.expression(a.Value)
+			exact = false
+		default:
+			args = append(args, c.expression(a))
+		}
+	}
+
+	fn, ok := prune(callee).(*Function)
+	if !exact {
+		if ok {
+			return fn.Return
+		}
+		return c.fresh()
+	}
+
+	ret := c.fresh()
+	if ok && (fn.Optional > 0 || fn.Variadic) {
+		return c.flexibleCall(exp, fn, args)
+	}
+	if ok && len(fn.Params) != len(args) {
+		c.errorf(exp.Token, "wrong number of arguments: want=%d, got=%d", len(fn.Params), len(args))
+		return ret
+	}
+
+	if err := unify(callee, &Function{Params: args, Return: ret}); err != nil {
+		c.errorf(exp.Token, "cannot call %s with (%s): %s", exp.Function, typeList(args), err)
+	}
+	return ret
+}
+
+// flexibleCall checks a call of a function with defaults or a rest
+// parameter, passing extra arguments to the rest parameter.
+func (c *checker) flexibleCall(exp *ast.CallExpression, fn *Function, args []Type) Type {
+	fixed := fn.fixed()
+	min := fixed - fn.Optional
+	switch {
+	case len(args) < min && fn.Variadic:
+		c.errorf(exp.Token, "wrong number of arguments: want at least %d, got=%d", min, len(args))
+		return fn.Return
+	case len(args) < min || !fn.Variadic && len(args) > fixed:
+		c.errorf(exp.Token, "wrong number of arguments: want=%d to %d, got=%d", min, fixed, len(args))
+		return fn.Return
+	}
+
+	for i, arg := range args {
+		var want Type
+		if i < fixed {
+			want = fn.Params[i]
+		} else {
+			want, arg = fn.Params[fixed], &Array{Element: arg}
+		}
+		if err := unify(want, arg); err != nil {
+			c.errorf(exp.Token, "cannot call %s with (%s): %s", exp.Function, typeList(args), err)
+			break
+		}
+	}
+	return fn.Return
+}
+
+func (c *checker) typeFromAnnotation(t ast.TypeExpr) Type {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		if b, ok := basics[t.Name]; ok {
+			return b
+		}
+		c.errorf(t.Token, "unknown type %s", t.Name)
+	case *ast.ArrayType:
+		return &Array{Element: c.typeFromAnnotation(t.Element)}
+	case *ast.FunctionType:
+		params := make([]Type, len(t.Parameters))
+		for i, p := range t.Parameters {
+			params[i] = c.typeFromAnnotation(p)
+		}
+		return &Function{Params: params, Return: c.typeFromAnnotation(t.Return)}
+	}
+	return c.fresh()
+}
+
+func (c *checker) instantiate(s *scheme) Type {
+	mapping := map[*Var]Type{}
+	for _, v := range s.vars {
+		fresh := c.fresh()
+		fresh.numeric = v.numeric
+		mapping[v] = fresh
+	}
+	return substitute(s.t, mapping)
+}
+
+func substitute(t Type, mapping map[*Var]Type) Type {
+	switch t := prune(t).(type) {
+	case *Var:
+		if r, ok := mapping[t]; ok {
+			return r
+		}
+		return t
+	case *Array:
+		return &Array{Element: substitute(t.Element, mapping)}
+	case *Iterator:
+		return &Iterator{Element: substitute(t.Element, mapping)}
+	case *Hash:
+		return &Hash{Key: substitute(t.Key, mapping), Value: substitute(t.Value, mapping)}
+	case *Function:
+		params := make([]Type, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = substitute(p, mapping)
+		}
+		return &Function{Params: params, Return: substitute(t.Return, mapping), Optional: t.Optional, Variadic: t.Variadic}
+	default:
+		return t
+	}
+}
+
+// generalize quantifies t over the variables that are not free in the
+// enclosing environment.
+func (c *checker) generalize(t Type) *scheme {
+	bound := map[*Var]bool{}
+	for e := c.env; e != nil; e = e.outer {
+		for _, s := range e.names {
+			quantified := map[*Var]bool{}
+			for _, v := range s.vars {
+				quantified[v] = true
+			}
+			for _, v := range freeVars(s.t) {
+				if !quantified[v] {
+					bound[v] = true
+				}
+			}
+		}
+	}
+	for _, r := range c.returns {
+		for _, v := range freeVars(r) {
+			bound[v] = true
+		}
+	}
+
+	s := &scheme{t: t}
+	for _, v := range freeVars(t) {
+		if !bound[v] {
+			s.vars = append(s.vars, v)
+		}
+	}
+	return s
+}
+
+func freeVars(t Type) []*Var {
+	switch t := prune(t).(type) {
+	case *Var:
+		return []*Var{t}
+	case *Array:
+		return freeVars(t.Element)
+	case *Iterator:
+		return freeVars(t.Element)
+	case *Hash:
+		return append(freeVars(t.Key), freeVars(t.Value)...)
+	case *Function:
+		vars := []*Var{}
+		for _, p := range t.Params {
+			vars = append(vars, freeVars(p)...)
+		}
+		return append(vars, freeVars(t.Return)...)
+	}
+	return nil
+}
+
+func typeList(ts []Type) string {
+	out := ""
+	for i, t := range ts {
+		if i > 0 {
+			out += ", "
+		}
+		out += resolve(t).String()
+	}
+	return out
+}
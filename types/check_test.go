@@ -0,0 +1,201 @@
+package types
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func check(t *testing.T, input string) (*ast.Program, *Info, []Error) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	info, errs := Check(program)
+	return program, info, errs
+}
+
+func defType(info *Info, name string) string {
+	for ident, t := range info.Defs {
+		if ident.Value == name {
+			return t.String()
+		}
+	}
+	return "<undefined>"
+}
+
+func TestInference(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		expected string
+	}{
+		{"let x = 5;", "x", "int"},
+		{`let s = "a" + "b";`, "s", "string"},
+		{"let b = 1 < 2;", "b", "bool"},
+		{"let a = [1, 2, 3];", "a", "[int]"},
+		{"let f = fn(x) { x + 1 };", "f", "fn(number) -> number"},
+		{"let f = fn(x) { x + 1 }; let y = f(1.5);", "y", "float"},
+		{"let f = fn(x) { x + 1 }; let y = f(1);", "y", "int"},
+		{"let f = fn(x) { x < 1 }; let y = f(1.5);", "y", "bool"},
+		{"let f = fn(x) { -x }; let y = f(1.5);", "y", "float"},
+		{"let f = fn(x: int, y) { if (x == 1) { y } else { 0 } };", "f", "fn(int, int) -> int"},
+		{`let e = 1 == "a";`, "e", "bool"},
+		{`let n = len("abc");`, "n", "int"},
+		{"let first = fn(xs) { xs[0] }; let y = first([true]);", "y", "bool"},
+		{
+			"let id = fn(x) { x }; let a = id(1); let b = id(\"s\");",
+			"b",
+			"string",
+		},
+		{
+			"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) };",
+			"fact",
+			"fn(int) -> int",
+		},
+		{"let g = fn(a: string) -> bool { true };", "g", "fn(string) -> bool"},
+		{"let h: fn(int) -> int = fn(x) { x };", "h", "fn(int) -> int"},
+		{"let e: [string] = [];", "e", "[string]"},
+		{`let h = {"a": 1}; let v = h["a"];`, "v", "int"},
+		{`let lt = "a" < "b";`, "lt", "bool"},
+		{"let x = 1.5 * 2;", "x", "float"},
+		{`let m = fn(v) { match (v) { [a, ...r] => a + 1, _ => 0 } };`, "m", "fn([int]) -> int"},
+		{`let a = [1, "a", true];`, "a", "[dynamic]"},
+		{`let a = [1, 2, "a"]; let x = a[0] + 1;`, "x", "dynamic"},
+		{`let h = {"a": 1, 2: "b"};`, "h", "{dynamic: dynamic}"},
+		{`let f = fn(x: bool) { if (x) { 1 } else { "no" } };`, "f", "fn(bool) -> dynamic"},
+		{`let f = fn(x) { [x, 1] };`, "f", "fn(int) -> [int]"},
+		{`let m = match (1) { 1 => "one", _ => 0 };`, "m", "dynamic"},
+		{`let m = fn(h) { match (h) { {"a": 1, "b": "x"} => true, _ => false } };`, "m", "fn({string: dynamic}) -> bool"},
+		{`let k = fn(h) { match (h) { {"kind": s} => s + "!" } };`, "k", "fn({string: string}) -> string"},
+		{"let f = fn(x) { x / 2.0 };", "f", "fn(number) -> float"},
+		{`struct P { x, y }; let p = P(1, "a"); let y = p.y;`, "y", "string"},
+		{"struct P { x }; let get = fn(p) { p.x + 1 }; let n = get(P(1));", "n", "number"},
+		{"struct P { x }; let p = P(1); let mk = fn(v) { P(v) };", "mk", "fn(int) -> P"},
+		{"let f = fn(a, b = 2) { a + b }; let r = f(1);", "f", "fn(number, int?) -> number"},
+		{"let f = fn(a, ...xs) { a + xs[0] + 1 }; let r = f(1, 2, 3);", "f", "fn(number, ...number) -> number"},
+		{"let f = fn(a, b = 1.5) { b }; let r = f(a: true);", "r", "float"},
+		{"let [a, b = 2, ...r] = [1]; let s = a + b;", "r", "[int]"},
+		{`let {name, nick = "x"} = {"name": "ann"}; let n = name;`, "n", "string"},
+		{"let y: float = -0.5;", "y", "float"},
+		{`let c = "abc"[0];`, "c", "string"},
+		{`let t = "abc"[1:];`, "t", "string"},
+		{"let xs = [1, 2][:1];", "xs", "[int]"},
+		{`let ws = split(upper("a b"), " ");`, "ws", "[string]"},
+		{"type P { x }; impl P { fn add(self, n) { self.x + n } }; let r = P(1).add(2);", "r", "int"},
+		{"type P { x }; impl P { fn add(self, n) { self.x + n } }; let f = P(1).add;", "f", "fn(int) -> int"},
+		{"type P { x }; impl P { fn add(self, n) { self.x * 2 + n } };", "add", "fn(P, number) -> number"},
+		{"type P { x }; let g = fn() { P(1).twice() + 1 }; impl P { fn twice(self) { self.x * 2 } };", "g", "fn() -> number"},
+		{`let s = select { v = a.recv() => "got", _ => "none" };`, "s", "string"},
+		{"let g = fn() { yield 1; yield 2 };", "g", "fn() -> iter[int]"},
+		{"let g = fn(x) { yield [x]; return 0 }; let it = g(true);", "it", "iter[[bool]]"},
+		{"let r = range(1, 5);", "r", "iter[int]"},
+		{"for (x in range(3)) { let y = x + 1 };", "y", "int"},
+		{`for ([i, s] in [[1, 2]]) { let u = i * s };`, "u", "int"},
+		{`for (c in "ab") { let u = upper(c) };`, "u", "string"},
+		{"let r = 1..10;", "r", "range"},
+		{"let n = 3; let x = (0..=n)[-1];", "x", "int"},
+		{"let s = (0..10)[2:];", "s", "range"},
+		{"for (i in 1..3) { let y = i * 2 };", "y", "int"},
+		{"let [a, ...b] = 0..3;", "b", "[int]"},
+		{"let g = fn() { yield true }; let [a] = g();", "a", "bool"},
+		{"let r: range = 1..=2;", "r", "range"},
+		{"let inc = fn(x) { x + 1 }; let r = 5 |> inc;", "r", "int"},
+		{`let add = fn(a, b) { a + b }; let r = "a" |> add(_, "b");`, "r", "string"},
+		{`let double = fn(x) { x * 2 }; let show = fn(n) { "n" }; let f = double >> show;`, "f", "fn(number) -> string"},
+		{"let add = fn(a, b) { a + b }; let neg = fn(x) { -x }; let f = add >> neg;", "f", "fn(number, number) -> number"},
+		{"let n = 8 >> 1;", "n", "int"},
+		{"enum R { Ok(v), Err(m) }; let r = R.Ok(1);", "r", "R"},
+		{`enum R { Ok(v), Err(m) }; let a = R.Err("x"); let f = fn(r) { match (r) { R.Ok(v) => v + 1, R.Err(_) => 0 } };`, "f", "fn(R) -> int"},
+		{`enum R { Ok(v), Err(m) }; let e = R.Err("x"); let g = fn(r) { match (r) { R.Err(m) => m, _ => "" } };`, "g", "fn(R) -> string"},
+		{"enum O { Some(v), None }; let n = O.None;", "n", "O"},
+		{"enum O { Some(v), None }; let s = O.Some(true); let mk = O.Some;", "mk", "fn(bool) -> O"},
+		{`type V { xs }; impl V { fn index(self, i) { self.xs[i] } }; let v = V(["a"])[0];`, "v", "string"},
+	}
+
+	for _, tt := range tests {
+		_, info, errs := check(t, tt.input)
+		if len(errs) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, errs)
+			continue
+		}
+
+		if got := defType(info, tt.name); got != tt.expected {
+			t.Errorf("wrong type for %s in %q. want=%s, got=%s", tt.name, tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 + "a"`, "1:3: type mismatch: int + string"},
+		{`let x: int = "five";`, "1:5: cannot use string as int"},
+		{`let f = fn(a: string, b: int) -> bool { a + b };`, "1:43: type mismatch: string + int"},
+		{`let f = fn(a) -> bool { a + 1 };`, "1:9: cannot use bool as number"},
+		{`let f = fn(x) { x }; f(1, 2);`, "1:23: wrong number of arguments: want=1, got=2"},
+		{`let f = fn(a, b = 1) { a }; f();`, "1:30: wrong number of arguments: want=1 to 2, got=0"},
+		{`let f = fn(a, ...r) { a }; f();`, "1:29: wrong number of arguments: want at least 1, got=0"},
+		{`let f = fn(...r) { r }; f(1, "a");`, "1:26: cannot call f with (int, string): cannot use string as int"},
+		{`let f = fn(a, b = "x") { a }; f(1, 2);`, "1:32: cannot call f with (int, int): cannot use int as string"},
+		{`struct P { x }; let p = P(1); p.x = "a";`, `1:35: cannot use string as int`},
+		{`struct P { x }; P(1).y`, "1:22: P has no field or method y"},
+		{`struct P { x }; struct Q { x }; let both = fn(f) { f(P(1)); f(Q(1)) };`, "1:62: cannot call f with (Q): cannot use Q as P"},
+		{`type P { x }; impl P { fn f(self) { 1 } }; P(1).g()`, "1:49: P has no field or method g"},
+		{`type P { x }; impl P { fn f(self) { self.x + 1 } }; P("a")`, "1:54: cannot call P with (string): cannot use string as number"},
+		{`let n = 1; impl n { fn f(self) { 1 } };`, "1:17: n is not a struct"},
+		{`type P { x }; impl P { fn f() { 1 } };`, "1:27: method f needs a parameter for the instance"},
+		{`enum R { Ok(v), Err(m) }; let f = fn(r) { match (r) { R.Ok(v) => v } };`, "1:43: match on R is not exhaustive: missing Err"},
+		{`enum C { R, G, B }; let f = fn(c) { match (c) { C.R => 1, C.G if true => 2 } };`, "1:37: match on C is not exhaustive: missing G, B"},
+		{`enum R { Ok(v) }; R.Ok(1); R.Ok("a")`, "1:32: cannot call (R.Ok) with (string): cannot use string as int"},
+		{`enum R { Ok(v) }; R.Nope`, "1:21: R has no variant Nope"},
+		{`enum R { Ok(v) }; match (1) { R.Ok(v) => v }`, "1:31: cannot use int as R"},
+		{`enum R { Ok(v) }; match (R.Ok(1)) { R.Ok(a, b) => a }`, "1:37: wrong number of fields in pattern R.Ok(a, b): want=1, got=2"},
+		{`let g = fn() { yield 1; yield "a" };`, "1:25: cannot use string as int"},
+		{`for (x in range(3)) { x + "a" }`, "1:25: type mismatch: int + string"},
+		{`range("a")`, "1:6: cannot call range with (string): cannot use string as int"},
+		{`1.."a"`, "1:2: cannot use string as int"},
+		{`(1..3)["a"]`, "1:7: cannot use string as int"},
+		{`let g = fn(x) { x + 1 }; "a" |> g`, "1:30: cannot call g with (string): cannot use string as number"},
+		{`let f = fn(x) { x + 1 } >> upper;`, "1:25: cannot use string as number"},
+		{`spawn fn() { 1 + true }`, "1:16: type mismatch: int + bool"},
+		{`-true`, "1:1: unknown operator: -bool"},
+		{`true + false`, "1:6: unknown operator: bool + bool"},
+		{`let y: widget = 1;`, "1:8: unknown type widget"},
+		{`{"a": 1}[2]`, "1:9: cannot use int as string"},
+		{`let a: [int] = [1, "two"];`, "1:16: cannot use string as int"},
+		{`let a: [[int]] = [[1], [1, "two"]];`, "1:24: cannot use string as int"},
+		{`true < false`, "1:6: unknown operator: bool < bool"},
+	}
+
+	for _, tt := range tests {
+		_, _, errs := check(t, tt.input)
+		if len(errs) == 0 {
+			t.Errorf("expected an error for %q", tt.input)
+			continue
+		}
+
+		if errs[0].Error() != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errs[0].Error())
+		}
+	}
+}
+
+func TestAnnotationsParseAndPrint(t *testing.T) {
+	input := "let f: fn(int, [string]) -> bool = fn(a: int, b: [string]) -> bool { true };"
+
+	program, _, errs := check(t, input)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	expected := "let f: fn(int, [string]) -> bool = fn(a: int, b: [string]) -> bool true;"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. want=%q, got=%q", expected, program.String())
+	}
+}
@@ -0,0 +1,284 @@
+// Package types implements an optional static type checker for Monkey.
+//
+// Check infers types Hindley-Milner style: every let binding is
+// generalized, so an unannotated `let id = fn(x) { x }` can be used at
+// several types, and annotations such as `let x: int = 5` or
+// `fn(a: string) -> bool` constrain inference where they are present.
+// Programs that fail to check still run; the evaluator ignores
+// annotations.
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Type interface {
+	String() string
+}
+
+// Basic is a type without structure such as int or string.
+type Basic struct {
+	Name string
+}
+
+func (b *Basic) String() string { return b.Name }
+
+var (
+	Int    = &Basic{Name: "int"}
+	Float  = &Basic{Name: "float"}
+	String = &Basic{Name: "string"}
+	Bool   = &Basic{Name: "bool"}
+	Null   = &Basic{Name: "null"}
+	Range  = &Basic{Name: "range"}
+
+	// Dynamic is the type of a value whose type is only known at run
+	// time, such as an element of [1, "a"]. It is compatible with every
+	// type.
+	Dynamic = &Basic{Name: "dynamic"}
+)
+
+var basics = map[string]*Basic{
+	"int":     Int,
+	"float":   Float,
+	"string":  String,
+	"bool":    Bool,
+	"null":    Null,
+	"range":   Range,
+	"dynamic": Dynamic,
+}
+
+type Array struct {
+	Element Type
+}
+
+func (a *Array) String() string { return "[" + a.Element.String() + "]" }
+
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+func (h *Hash) String() string { return "{" + h.Key.String() + ": " + h.Value.String() + "}" }
+
+// Iterator is the type of the lazy sequences made by generators and the
+// iterator builtins.
+type Iterator struct {
+	Element Type
+}
+
+func (i *Iterator) String() string { return "iter[" + i.Element.String() + "]" }
+
+// Function is the type of a function. The last Optional of its Params
+// (before the rest parameter, if Variadic) have defaults. A Variadic
+// function's last param is the array collecting the remaining arguments.
+// Neither affects unification.
+type Function struct {
+	Params   []Type
+	Return   Type
+	Optional int
+	Variadic bool
+}
+
+func (f *Function) String() string {
+	params := []string{}
+	fixed := f.fixed()
+	for i, p := range f.Params {
+		switch {
+		case i >= fixed:
+			if a, ok := prune(p).(*Array); ok {
+				p = a.Element
+			}
+			params = append(params, "..."+p.String())
+		case i >= fixed-f.Optional:
+			params = append(params, p.String()+"?")
+		default:
+			params = append(params, p.String())
+		}
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.Return.String()
+}
+
+// Struct is the type of the instances of one struct declaration. Two
+// declarations with the same fields are still different types. Field
+// types are inferred from how the struct is used, the same for every
+// instance. Methods holds the types of the methods added by impl
+// blocks, each taking the instance as its first parameter.
+type Struct struct {
+	Name    string
+	Fields  map[string]Type
+	Methods map[string]Type
+}
+
+func (s *Struct) String() string { return s.Name }
+
+// Enum is the type of the values of one enum declaration. Like struct
+// fields, the field types of each variant are the same for every value.
+type Enum struct {
+	Name     string
+	Names    []string
+	Variants map[string][]Type
+}
+
+func (e *Enum) String() string { return e.Name }
+
+// EnumDecl is the type of the name of an enum, whose members are its
+// variants.
+type EnumDecl struct {
+	Enum *Enum
+}
+
+func (d *EnumDecl) String() string { return "enum " + d.Enum.Name }
+
+// fixed returns the number of params that are not the rest parameter.
+func (f *Function) fixed() int {
+	if f.Variadic {
+		return len(f.Params) - 1
+	}
+	return len(f.Params)
+}
+
+// Var is a type variable. Once bound to another type by unification it
+// stands for that type. A numeric variable, such as the type of x in
+// fn(x) { x + 1 }, can only be bound to int or float.
+type Var struct {
+	id       int
+	instance Type
+	numeric  bool
+}
+
+func (v *Var) String() string {
+	if v.instance != nil {
+		return v.instance.String()
+	}
+	if v.numeric {
+		return "number"
+	}
+	return fmt.Sprintf("t%d", v.id)
+}
+
+// scheme is a type generalized over some of its variables.
+type scheme struct {
+	vars []*Var
+	t    Type
+}
+
+// prune follows bound type variables to the type they stand for. It
+// does not shorten the chains it follows, so that unbinding the
+// variables a failed unify bound undoes it completely.
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Var)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+func occursIn(v *Var, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Var:
+		return t == v
+	case *Array:
+		return occursIn(v, t.Element)
+	case *Iterator:
+		return occursIn(v, t.Element)
+	case *Hash:
+		return occursIn(v, t.Key) || occursIn(v, t.Value)
+	case *Function:
+		for _, p := range t.Params {
+			if occursIn(v, p) {
+				return true
+			}
+		}
+		return occursIn(v, t.Return)
+	}
+	return false
+}
+
+func unify(a, b Type) error {
+	a, b = prune(a), prune(b)
+
+	if v, ok := a.(*Var); ok {
+		if v == b {
+			return nil
+		}
+		if v.numeric {
+			if w, ok := b.(*Var); ok {
+				w.numeric = true
+			} else if b != Int && b != Float && b != Dynamic {
+				return fmt.Errorf("cannot use %s as number", b)
+			}
+		}
+		if occursIn(v, b) {
+			return fmt.Errorf("recursive type %s = %s", v, b)
+		}
+		v.instance = b
+		return nil
+	}
+	if _, ok := b.(*Var); ok {
+		return unify(b, a)
+	}
+	if a == Dynamic || b == Dynamic {
+		return nil
+	}
+
+	switch a := a.(type) {
+	case *Basic, *Struct, *Enum, *EnumDecl:
+		if a == b {
+			return nil
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return unify(a.Element, b.Element)
+		}
+	case *Iterator:
+		if b, ok := b.(*Iterator); ok {
+			return unify(a.Element, b.Element)
+		}
+	case *Hash:
+		if b, ok := b.(*Hash); ok {
+			if err := unify(a.Key, b.Key); err != nil {
+				return err
+			}
+			return unify(a.Value, b.Value)
+		}
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok {
+			break
+		}
+		if len(a.Params) != len(b.Params) {
+			return fmt.Errorf("function arity mismatch: %s and %s", a, b)
+		}
+		for i := range a.Params {
+			if err := unify(a.Params[i], b.Params[i]); err != nil {
+				return err
+			}
+		}
+		return unify(a.Return, b.Return)
+	}
+
+	return fmt.Errorf("cannot use %s as %s", b, a)
+}
+
+// resolve returns t with every bound variable replaced by its instance.
+func resolve(t Type) Type {
+	switch t := prune(t).(type) {
+	case *Array:
+		return &Array{Element: resolve(t.Element)}
+	case *Iterator:
+		return &Iterator{Element: resolve(t.Element)}
+	case *Hash:
+		return &Hash{Key: resolve(t.Key), Value: resolve(t.Value)}
+	case *Function:
+		params := make([]Type, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = resolve(p)
+		}
+		return &Function{Params: params, Return: resolve(t.Return), Optional: t.Optional, Variadic: t.Variadic}
+	default:
+		return t
+	}
+}